@@ -1,7 +1,13 @@
 package subnets
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
 	"net"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -132,7 +138,7 @@ func TestCalculateSubnetMaksFromAddressBits(t *testing.T) {
 		description             string
 		potentialAddressPortion []uint32
 		expectedNetMask         net.IPMask
-		expectedTotalHosts      uint32
+		expectedTotalHosts      uint64
 	}{
 		{
 			description:             "2 address bits set, smallest usable mask",
@@ -164,6 +170,12 @@ func TestCalculateSubnetMaksFromAddressBits(t *testing.T) {
 			expectedNetMask:         net.CIDRMask(22, 32),
 			expectedTotalHosts:      1024,
 		},
+		{
+			description:             "32 address bits set",
+			potentialAddressPortion: []uint32{0x80000000, 0xFFFFFFFF},
+			expectedNetMask:         net.CIDRMask(0, 32),
+			expectedTotalHosts:      1 << 32,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.description, func(t *testing.T) {
@@ -180,3 +192,4626 @@ func TestCalculateSubnetMaksFromAddressBits(t *testing.T) {
 		})
 	}
 }
+
+func TestSubnetID(t *testing.T) {
+	g := NewWithT(t)
+
+	first, err := CalculateSubnet("10.0.0.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	second, err := CalculateSubnet("10.0.1.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	g.Expect(first.ID()).To(BeIdenticalTo(uint64(0x0A000000)<<8 | 24))
+	g.Expect(first.ID()).NotTo(Equal(second.ID()))
+	g.Expect(first.ID()).To(BeNumerically("<", second.ID()))
+}
+
+func TestCalculateSubnetFromInt(t *testing.T) {
+	tests := []struct {
+		description string
+		networkInt  uint32
+		prefix      int
+		expectedErr bool
+		cidr        string
+	}{
+		{
+			description: "10.0.0.0/24 from integer",
+			networkInt:  0x0A000000,
+			prefix:      24,
+			cidr:        "10.0.0.0/24",
+		},
+		{
+			description: "192.168.16.0/20 from integer",
+			networkInt:  0xC0A81000,
+			prefix:      20,
+			cidr:        "192.168.16.0/20",
+		},
+		{
+			description: "host address from integer",
+			networkInt:  0x0A000082,
+			prefix:      25,
+			cidr:        "10.0.0.130/25",
+		},
+		{
+			description: "whole address space from integer",
+			networkInt:  0,
+			prefix:      0,
+			cidr:        "0.0.0.0/0",
+		},
+		{
+			description: "single address from integer",
+			networkInt:  0xFFFFFFFF,
+			prefix:      32,
+			cidr:        "255.255.255.255/32",
+		},
+		{
+			description: "prefix out of range",
+			networkInt:  0x0A000000,
+			prefix:      33,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnetFromInt(tt.networkInt, tt.prefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			expected, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet).To(Equal(expected))
+		})
+	}
+}
+
+func TestSubnetAlign(t *testing.T) {
+	tests := []struct {
+		description     string
+		cidr            string
+		expectedAligned string
+	}{
+		{
+			description:     "misaligned /25",
+			cidr:            "10.0.0.130/25",
+			expectedAligned: "10.0.0.128/25",
+		},
+		{
+			description:     "already aligned /24",
+			cidr:            "10.0.0.0/24",
+			expectedAligned: "10.0.0.0/24",
+		},
+		{
+			description:     "misaligned IPv6 /64",
+			cidr:            "2001:db8::1/64",
+			expectedAligned: "2001:db8::/64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			expected, err := CalculateSubnet(tt.expectedAligned)
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			aligned := subnet.Align()
+			g.Expect(aligned).To(Equal(expected))
+			g.Expect(subnet.NetworkCIDR).To(Equal(tt.cidr))
+		})
+	}
+}
+
+func TestSubnetAlignZeroValue(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet := &Subnet{}
+	g.Expect(subnet.Align()).To(BeNil())
+	g.Expect(func() { _ = subnet.DetailedString() }).NotTo(Panic())
+	_, _, err := subnet.Widen()
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestLongestPrefixMatch(t *testing.T) {
+	tests := []struct {
+		description   string
+		routes        []string
+		dest          string
+		expectedRoute string
+		expectedErr   bool
+	}{
+		{
+			description:   "/24 wins over overlapping /16",
+			routes:        []string{"10.0.0.0/16", "10.0.1.0/24", "0.0.0.0/0"},
+			dest:          "10.0.1.42",
+			expectedRoute: "10.0.1.0/24",
+		},
+		{
+			description:   "/16 matches outside of /24",
+			routes:        []string{"10.0.1.0/24", "10.0.0.0/16"},
+			dest:          "10.0.2.42",
+			expectedRoute: "10.0.0.0/16",
+		},
+		{
+			description: "no matching route",
+			routes:      []string{"10.0.0.0/16", "10.0.1.0/24"},
+			dest:        "192.168.0.1",
+			expectedErr: true,
+		},
+		{
+			description: "invalid destination",
+			routes:      []string{"10.0.0.0/16"},
+			dest:        "10.0.0",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			route, err := LongestPrefixMatch(tt.routes, tt.dest)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(route.NetworkCIDR).To(Equal(tt.expectedRoute))
+		})
+	}
+}
+
+func TestEfficiencyForPrefix(t *testing.T) {
+	tests := []struct {
+		description        string
+		hostsNeeded        int
+		prefix             int
+		expectedEfficiency float64
+	}{
+		{
+			description:        "100 hosts in a /24",
+			hostsNeeded:        100,
+			prefix:             24,
+			expectedEfficiency: 100.0 / 254.0,
+		},
+		{
+			description:        "100 hosts in a /25",
+			hostsNeeded:        100,
+			prefix:             25,
+			expectedEfficiency: 100.0 / 126.0,
+		},
+		{
+			description:        "1 host in a /32",
+			hostsNeeded:        1,
+			prefix:             32,
+			expectedEfficiency: 1,
+		},
+		{
+			description:        "invalid prefix",
+			hostsNeeded:        1,
+			prefix:             33,
+			expectedEfficiency: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(EfficiencyForPrefix(tt.hostsNeeded, tt.prefix)).To(BeNumerically("~", tt.expectedEfficiency))
+		})
+	}
+	g := NewWithT(t)
+	g.Expect(EfficiencyForPrefix(100, 25)).To(BeNumerically(">", EfficiencyForPrefix(100, 24)))
+}
+
+func TestSplitByRatio(t *testing.T) {
+	tests := []struct {
+		description   string
+		parent        string
+		leftBits      int
+		expectedLeft  string
+		expectedRight string
+		expectedErr   bool
+	}{
+		{
+			description:   "/24 into two halves",
+			parent:        "10.0.0.0/24",
+			leftBits:      1,
+			expectedLeft:  "10.0.0.0/25",
+			expectedRight: "10.0.0.128/25",
+		},
+		{
+			description:   "/24 into a quarter and a half",
+			parent:        "10.0.0.0/24",
+			leftBits:      2,
+			expectedLeft:  "10.0.0.0/26",
+			expectedRight: "10.0.0.128/25",
+		},
+		{
+			description: "left bits exceed address space",
+			parent:      "10.0.0.0/30",
+			leftBits:    3,
+			expectedErr: true,
+		},
+		{
+			description: "zero left bits",
+			parent:      "10.0.0.0/24",
+			leftBits:    0,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			children, err := SplitByRatio(tt.parent, tt.leftBits)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(children[0].NetworkCIDR).To(Equal(tt.expectedLeft))
+			g.Expect(children[1].NetworkCIDR).To(Equal(tt.expectedRight))
+		})
+	}
+}
+
+func TestSubnetHostOffset(t *testing.T) {
+	tests := []struct {
+		description    string
+		cidr           string
+		ip             net.IP
+		expectedOffset int
+		expectedErr    bool
+	}{
+		{
+			description:    "10.0.0.5 in 10.0.0.0/24",
+			cidr:           "10.0.0.0/24",
+			ip:             net.ParseIP("10.0.0.5"),
+			expectedOffset: 5,
+		},
+		{
+			description:    "network address in 10.0.4.0/22",
+			cidr:           "10.0.4.0/22",
+			ip:             net.ParseIP("10.0.4.0"),
+			expectedOffset: 0,
+		},
+		{
+			description:    "10.0.6.1 in 10.0.4.0/22",
+			cidr:           "10.0.4.0/22",
+			ip:             net.ParseIP("10.0.6.1"),
+			expectedOffset: 513,
+		},
+		{
+			description: "IP outside of subnet",
+			cidr:        "10.0.0.0/24",
+			ip:          net.ParseIP("10.0.1.5"),
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			offset, err := subnet.HostOffset(tt.ip)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(offset).To(BeIdenticalTo(tt.expectedOffset))
+		})
+	}
+}
+
+func TestCalculateSubnetsByCIDRPrefixes(t *testing.T) {
+	tests := []struct {
+		description         string
+		sourceNetCIDR       string
+		subnetCIDR          uint32
+		expectedSubnetCount int
+		expectedErr         bool
+	}{
+		{
+			description:         "100.64.0.0/16 --> /22 CIDR",
+			sourceNetCIDR:       "100.64.0.0/16",
+			subnetCIDR:          22,
+			expectedSubnetCount: 64,
+		},
+		{
+			description:         "10.0.0.0/24 --> /26 CIDR",
+			sourceNetCIDR:       "10.0.0.0/24",
+			subnetCIDR:          26,
+			expectedSubnetCount: 4,
+		},
+		{
+			description:   "child prefix shorter than parent",
+			sourceNetCIDR: "10.0.0.0/24",
+			subnetCIDR:    16,
+			expectedErr:   true,
+		},
+		{
+			description:   "child prefix equal to parent",
+			sourceNetCIDR: "10.0.0.0/24",
+			subnetCIDR:    24,
+			expectedErr:   true,
+		},
+		{
+			description:   "child prefix exceeding 32 bits",
+			sourceNetCIDR: "10.0.0.0/24",
+			subnetCIDR:    33,
+			expectedErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			prefixes, err := CalculateSubnetsByCIDRPrefixes(tt.sourceNetCIDR, tt.subnetCIDR)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			subnets, err := CalculateSubnetsByCIDR(tt.sourceNetCIDR, tt.subnetCIDR)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(len(prefixes)).To(BeIdenticalTo(tt.expectedSubnetCount))
+			g.Expect(len(prefixes)).To(BeIdenticalTo(len(subnets)))
+			for i, prefix := range prefixes {
+				g.Expect(prefix.String()).To(Equal(subnets[i].Network.String()))
+			}
+		})
+	}
+}
+
+func TestSubnetBINDGenerate(t *testing.T) {
+	tests := []struct {
+		description       string
+		cidr              string
+		domainTemplate    string
+		expectedDirective string
+		expectedErr       bool
+	}{
+		{
+			description:       "/24 subnet",
+			cidr:              "192.168.1.0/24",
+			domainTemplate:    "host-$.example.com.",
+			expectedDirective: "$GENERATE 1-254 $ PTR host-$.example.com.",
+		},
+		{
+			description:       "/26 subnet",
+			cidr:              "192.168.1.64/26",
+			domainTemplate:    "host-$.example.com.",
+			expectedDirective: "$GENERATE 65-126 $ PTR host-$.example.com.",
+		},
+		{
+			description:    "/23 subnet is not supported",
+			cidr:           "192.168.0.0/23",
+			domainTemplate: "host-$.example.com.",
+			expectedErr:    true,
+		},
+		{
+			description: "empty domain template",
+			cidr:        "192.168.1.0/24",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			directive, err := subnet.BINDGenerate(tt.domainTemplate)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(directive).To(Equal(tt.expectedDirective))
+		})
+	}
+}
+
+func TestTotalChildrenAcrossParents(t *testing.T) {
+	tests := []struct {
+		description        string
+		parents            []string
+		childPrefix        int
+		expectedTotalCount int
+		expectedErr        bool
+	}{
+		{
+			description:        "/24 children of two /16 parents",
+			parents:            []string{"10.0.0.0/16", "10.1.0.0/16"},
+			childPrefix:        24,
+			expectedTotalCount: 512,
+		},
+		{
+			description:        "/24 children of mixed parents",
+			parents:            []string{"10.0.0.0/22", "10.1.0.0/24"},
+			childPrefix:        24,
+			expectedTotalCount: 5,
+		},
+		{
+			description: "child prefix shorter than a parent",
+			parents:     []string{"10.0.0.0/16", "10.1.0.0/25"},
+			childPrefix: 24,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			total, err := TotalChildrenAcrossParents(tt.parents, tt.childPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(total).To(BeIdenticalTo(tt.expectedTotalCount))
+		})
+	}
+}
+
+func TestCanAggregate(t *testing.T) {
+	tests := []struct {
+		description      string
+		a                string
+		b                string
+		expectedOK       bool
+		expectedSupernet string
+	}{
+		{
+			description:      "two /25 halves",
+			a:                "10.0.0.0/25",
+			b:                "10.0.0.128/25",
+			expectedOK:       true,
+			expectedSupernet: "10.0.0.0/24",
+		},
+		{
+			description:      "two /25 halves in reverse order",
+			a:                "10.0.0.128/25",
+			b:                "10.0.0.0/25",
+			expectedOK:       true,
+			expectedSupernet: "10.0.0.0/24",
+		},
+		{
+			description: "adjacent but not aligned /24s",
+			a:           "10.0.1.0/24",
+			b:           "10.0.2.0/24",
+		},
+		{
+			description: "different prefix lengths",
+			a:           "10.0.0.0/25",
+			b:           "10.0.0.128/26",
+		},
+		{
+			description: "identical subnets",
+			a:           "10.0.0.0/24",
+			b:           "10.0.0.0/24",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			a, err := CalculateSubnet(tt.a)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			b, err := CalculateSubnet(tt.b)
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			ok, supernet := CanAggregate(a, b)
+			g.Expect(ok).To(Equal(tt.expectedOK))
+			if !tt.expectedOK {
+				g.Expect(supernet).To(BeNil())
+				return
+			}
+			g.Expect(supernet.NetworkCIDR).To(Equal(tt.expectedSupernet))
+		})
+	}
+}
+
+func TestStepSize(t *testing.T) {
+	tests := []struct {
+		description   string
+		prefix        int
+		expectedOctet int
+		expectedStep  int
+	}{
+		{
+			description:   "/18",
+			prefix:        18,
+			expectedOctet: 3,
+			expectedStep:  64,
+		},
+		{
+			description:   "/20",
+			prefix:        20,
+			expectedOctet: 3,
+			expectedStep:  16,
+		},
+		{
+			description:   "/24",
+			prefix:        24,
+			expectedOctet: 3,
+			expectedStep:  1,
+		},
+		{
+			description:   "/26",
+			prefix:        26,
+			expectedOctet: 4,
+			expectedStep:  64,
+		},
+		{
+			description:   "/0",
+			prefix:        0,
+			expectedOctet: 1,
+			expectedStep:  256,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			octet, step := StepSize(tt.prefix)
+			g.Expect(octet).To(BeIdenticalTo(tt.expectedOctet))
+			g.Expect(step).To(BeIdenticalTo(tt.expectedStep))
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, errs := ParseAll([]string{"10.0.0.0/24", "10.0.0.300/24", "192.168.0.0/16", "no cidr"})
+	g.Expect(subnets).To(HaveLen(2))
+	g.Expect(subnets[0].NetworkCIDR).To(Equal("10.0.0.0/24"))
+	g.Expect(subnets[1].NetworkCIDR).To(Equal("192.168.0.0/16"))
+	g.Expect(errs).To(HaveLen(2))
+	g.Expect(errs[0].Error()).To(HavePrefix("line 2:"))
+	g.Expect(errs[1].Error()).To(HavePrefix("line 4:"))
+}
+
+func TestHostInts(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		expectedHosts []uint32
+	}{
+		{
+			description:   "/29 subnet",
+			cidr:          "10.0.0.8/29",
+			expectedHosts: []uint32{0x0A000009, 0x0A00000A, 0x0A00000B, 0x0A00000C, 0x0A00000D, 0x0A00000E},
+		},
+		{
+			description:   "/30 subnet",
+			cidr:          "192.168.1.4/30",
+			expectedHosts: []uint32{0xC0A80105, 0xC0A80106},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			hosts, err := HostInts(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(hosts).To(Equal(tt.expectedHosts))
+		})
+	}
+}
+
+func TestFitsWithinParent(t *testing.T) {
+	tests := []struct {
+		description   string
+		parent        string
+		childPrefixes []int
+		expectedFits  bool
+		expectedErr   bool
+	}{
+		{
+			description:   "children exactly fill a /24",
+			parent:        "10.0.0.0/24",
+			childPrefixes: []int{26, 25, 27, 27},
+			expectedFits:  true,
+		},
+		{
+			description:   "children exceed a /24",
+			parent:        "10.0.0.0/24",
+			childPrefixes: []int{25, 25, 30},
+			expectedFits:  false,
+		},
+		{
+			description:   "child larger than parent",
+			parent:        "10.0.0.0/24",
+			childPrefixes: []int{23},
+			expectedErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fits, err := FitsWithinParent(tt.parent, tt.childPrefixes)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(fits).To(Equal(tt.expectedFits))
+		})
+	}
+}
+
+func TestSubnetWiden(t *testing.T) {
+	tests := []struct {
+		description         string
+		cidr                string
+		expectedParent      string
+		expectedAddressGain int
+		expectedErr         bool
+	}{
+		{
+			description:         "/24 to /23",
+			cidr:                "10.0.0.0/24",
+			expectedParent:      "10.0.0.0/23",
+			expectedAddressGain: 256,
+		},
+		{
+			description:         "upper /24 to /23",
+			cidr:                "10.0.1.0/24",
+			expectedParent:      "10.0.0.0/23",
+			expectedAddressGain: 256,
+		},
+		{
+			description: "/0 can not be widened",
+			cidr:        "0.0.0.0/0",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			parent, addressGain, err := subnet.Widen()
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(parent.NetworkCIDR).To(Equal(tt.expectedParent))
+			g.Expect(addressGain).To(BeIdenticalTo(tt.expectedAddressGain))
+		})
+	}
+}
+
+func TestCalculateSubnetLeadingZeros(t *testing.T) {
+	tests := []struct {
+		description  string
+		cidr         string
+		expectedCIDR string
+	}{
+		{
+			description:  "leading zero in first octet",
+			cidr:         "010.0.0.0/24",
+			expectedCIDR: "10.0.0.0/24",
+		},
+		{
+			description:  "leading zeros in all octets",
+			cidr:         "010.000.001.000/24",
+			expectedCIDR: "10.0.1.0/24",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			expected, err := CalculateSubnet(tt.expectedCIDR)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet).To(Equal(expected))
+		})
+	}
+}
+
+func TestSubnetHostForKey(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("10.0.0.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	hostMin := ipToInt(subnet.HostMinIP)
+	hostMax := ipToInt(subnet.HostMaxIP)
+	for _, key := range []string{"web-1", "web-2", "db", ""} {
+		ip := subnet.HostForKey(key)
+		g.Expect(ip).NotTo(BeNil())
+		g.Expect(ip.Equal(subnet.HostForKey(key))).To(BeTrue())
+		g.Expect(ipToInt(ip)).To(And(BeNumerically(">=", hostMin), BeNumerically("<=", hostMax)))
+	}
+	g.Expect(subnet.HostForKey("web-1").Equal(subnet.HostForKey("web-2"))).To(BeFalse())
+}
+
+func TestCalculateSubnetsNamed(t *testing.T) {
+	tests := []struct {
+		description     string
+		parent          string
+		childPrefix     int
+		names           []string
+		expectedSubnets map[string]string
+		expectedErr     bool
+	}{
+		{
+			description: "four /26 children of a /24",
+			parent:      "10.0.0.0/24",
+			childPrefix: 26,
+			names:       []string{"web", "app", "db", "mgmt"},
+			expectedSubnets: map[string]string{
+				"web":  "10.0.0.0/26",
+				"app":  "10.0.0.64/26",
+				"db":   "10.0.0.128/26",
+				"mgmt": "10.0.0.192/26",
+			},
+		},
+		{
+			description: "less names than children",
+			parent:      "10.0.0.0/24",
+			childPrefix: 26,
+			names:       []string{"web"},
+			expectedSubnets: map[string]string{
+				"web": "10.0.0.0/26",
+			},
+		},
+		{
+			description: "more names than children",
+			parent:      "10.0.0.0/24",
+			childPrefix: 25,
+			names:       []string{"web", "app", "db"},
+			expectedErr: true,
+		},
+		{
+			description: "duplicate names",
+			parent:      "10.0.0.0/24",
+			childPrefix: 25,
+			names:       []string{"web", "web"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := CalculateSubnetsNamed(tt.parent, tt.childPrefix, tt.names)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for name, cidr := range tt.expectedSubnets {
+				g.Expect(subnets).To(HaveKey(name))
+				g.Expect(subnets[name].NetworkCIDR).To(Equal(cidr))
+			}
+		})
+	}
+}
+
+func TestSubnetHostSample(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		n             int
+		expectedFirst []string
+		expectedLast  []string
+	}{
+		{
+			description:   "/24 with n=3",
+			cidr:          "10.0.0.0/24",
+			n:             3,
+			expectedFirst: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+			expectedLast:  []string{"10.0.0.252", "10.0.0.253", "10.0.0.254"},
+		},
+		{
+			description:   "/29 with less than 2n hosts",
+			cidr:          "10.0.0.0/29",
+			n:             4,
+			expectedFirst: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"},
+			expectedLast:  []string{"10.0.0.5", "10.0.0.6"},
+		},
+		{
+			description:   "/30 with more than n hosts",
+			cidr:          "10.0.0.0/30",
+			n:             5,
+			expectedFirst: []string{"10.0.0.1", "10.0.0.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			first, last := subnet.HostSample(tt.n)
+			g.Expect(first).To(HaveLen(len(tt.expectedFirst)))
+			for i, ip := range first {
+				g.Expect(ip.String()).To(Equal(tt.expectedFirst[i]))
+			}
+			g.Expect(last).To(HaveLen(len(tt.expectedLast)))
+			for i, ip := range last {
+				g.Expect(ip.String()).To(Equal(tt.expectedLast[i]))
+			}
+		})
+	}
+}
+
+func TestRangeCrossesBoundary(t *testing.T) {
+	tests := []struct {
+		description     string
+		start           string
+		end             string
+		prefix          int
+		expectedCrosses bool
+		expectedErr     bool
+	}{
+		{
+			description:     "range spans two /24s",
+			start:           "10.0.0.250",
+			end:             "10.0.1.10",
+			prefix:          24,
+			expectedCrosses: true,
+		},
+		{
+			description:     "range within a single /24",
+			start:           "10.0.0.10",
+			end:             "10.0.0.250",
+			prefix:          24,
+			expectedCrosses: false,
+		},
+		{
+			description:     "range within a single /23",
+			start:           "10.0.0.250",
+			end:             "10.0.1.10",
+			prefix:          23,
+			expectedCrosses: false,
+		},
+		{
+			description: "start after end",
+			start:       "10.0.1.10",
+			end:         "10.0.0.250",
+			prefix:      24,
+			expectedErr: true,
+		},
+		{
+			description: "invalid address",
+			start:       "10.0.0",
+			end:         "10.0.0.250",
+			prefix:      24,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			crosses, err := RangeCrossesBoundary(tt.start, tt.end, tt.prefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(crosses).To(Equal(tt.expectedCrosses))
+		})
+	}
+}
+
+func TestSubnetMikroTikAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("10.0.0.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.MikroTikAddress("ether1")).To(Equal("/ip address add address=10.0.0.1/24 interface=ether1"))
+
+	subnet, err = CalculateSubnet("2001:db8::/64")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.MikroTikAddress("ether1")).To(Equal("/ipv6 address add address=2001:db8::/64 interface=ether1"))
+}
+
+func TestAllocationSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, errs := ParseAll([]string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/25", "10.0.2.128/30"})
+	g.Expect(errs).To(BeEmpty())
+
+	summary := AllocationSummary(subnets)
+	g.Expect(summary.Count).To(BeIdenticalTo(4))
+	g.Expect(summary.TotalAddresses).To(BeIdenticalTo(int64(256 + 256 + 128 + 4)))
+	g.Expect(summary.TotalUsable).To(BeIdenticalTo(int64(254 + 254 + 126 + 2)))
+	g.Expect(summary.Prefixes).To(Equal(map[int]int{24: 2, 25: 1, 30: 1}))
+
+	subnets, errs = ParseAll([]string{"10.0.0.0/24", "2001:db8::/65", "2001:db8:1::/64"})
+	g.Expect(errs).To(BeEmpty())
+
+	summary = AllocationSummary(subnets)
+	g.Expect(summary.Count).To(BeIdenticalTo(3))
+	g.Expect(summary.TotalAddresses).To(BeIdenticalTo(int64(math.MaxInt64)))
+	g.Expect(summary.TotalUsable).To(BeIdenticalTo(int64(math.MaxInt64)))
+	g.Expect(summary.Prefixes).To(Equal(map[int]int{24: 1, 64: 1, 65: 1}))
+}
+
+func TestFreeNeighbors(t *testing.T) {
+	tests := []struct {
+		description    string
+		subnet         string
+		used           []string
+		parent         string
+		expectedBefore string
+		expectedAfter  string
+		expectedErr    bool
+	}{
+		{
+			description:   "free space only after the subnet",
+			subnet:        "10.0.1.0/24",
+			used:          []string{"10.0.0.0/24", "10.0.1.0/24"},
+			parent:        "10.0.0.0/22",
+			expectedAfter: "10.0.2.0/24",
+		},
+		{
+			description:    "free space on both sides",
+			subnet:         "10.0.1.0/24",
+			used:           []string{"10.0.1.0/24"},
+			parent:         "10.0.0.0/22",
+			expectedBefore: "10.0.0.0/24",
+			expectedAfter:  "10.0.2.0/24",
+		},
+		{
+			description: "partially used neighbor and parent boundary",
+			subnet:      "10.0.3.0/24",
+			used:        []string{"10.0.2.128/25"},
+			parent:      "10.0.0.0/22",
+		},
+		{
+			description: "subnet outside the parent",
+			subnet:      "10.0.4.0/24",
+			parent:      "10.0.0.0/22",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			before, after, err := FreeNeighbors(tt.subnet, tt.used, tt.parent)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			if tt.expectedBefore == "" {
+				g.Expect(before).To(BeNil())
+			} else {
+				g.Expect(before.NetworkCIDR).To(Equal(tt.expectedBefore))
+			}
+			if tt.expectedAfter == "" {
+				g.Expect(after).To(BeNil())
+			} else {
+				g.Expect(after.NetworkCIDR).To(Equal(tt.expectedAfter))
+			}
+		})
+	}
+}
+
+func TestSubnetIsProperBlock(t *testing.T) {
+	tests := []struct {
+		description    string
+		cidr           string
+		expectedProper bool
+	}{
+		{
+			description:    "aligned /26",
+			cidr:           "10.0.0.0/26",
+			expectedProper: true,
+		},
+		{
+			description:    "host bits set in /26",
+			cidr:           "10.0.0.32/26",
+			expectedProper: false,
+		},
+		{
+			description:    "aligned /26 at second block",
+			cidr:           "10.0.0.64/26",
+			expectedProper: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.IsProperBlock()).To(Equal(tt.expectedProper))
+		})
+	}
+}
+
+func TestChildPrefixForHosts(t *testing.T) {
+	tests := []struct {
+		description    string
+		parent         string
+		hosts          int
+		expectedPrefix int
+		expectedErr    bool
+	}{
+		{
+			description:    "1000 hosts in a /20",
+			parent:         "10.0.0.0/20",
+			hosts:          1000,
+			expectedPrefix: 22,
+		},
+		{
+			description:    "254 hosts in a /24",
+			parent:         "10.0.0.0/24",
+			hosts:          254,
+			expectedPrefix: 24,
+		},
+		{
+			description:    "2 hosts in a /24",
+			parent:         "10.0.0.0/24",
+			hosts:          2,
+			expectedPrefix: 30,
+		},
+		{
+			description: "255 hosts exceed a /24",
+			parent:      "10.0.0.0/24",
+			hosts:       255,
+			expectedErr: true,
+		},
+		{
+			description: "no hosts",
+			parent:      "10.0.0.0/24",
+			hosts:       0,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			prefix, err := ChildPrefixForHosts(tt.parent, tt.hosts)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(prefix).To(BeIdenticalTo(tt.expectedPrefix))
+		})
+	}
+}
+
+func TestSubnetBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		description  string
+		cidr         string
+		expectedData []byte
+	}{
+		{
+			description:  "/24 subnet",
+			cidr:         "10.0.1.0/24",
+			expectedData: []byte{10, 0, 1, 0, 24},
+		},
+		{
+			description:  "/0 subnet",
+			cidr:         "0.0.0.0/0",
+			expectedData: []byte{0, 0, 0, 0, 0},
+		},
+		{
+			description:  "/30 subnet",
+			cidr:         "192.168.1.4/30",
+			expectedData: []byte{192, 168, 1, 4, 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			data, err := subnet.MarshalBinary()
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(data).To(Equal(tt.expectedData))
+
+			decoded := &Subnet{}
+			g.Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+			g.Expect(decoded).To(Equal(subnet))
+		})
+	}
+
+	g := NewWithT(t)
+	g.Expect((&Subnet{}).UnmarshalBinary([]byte{10, 0, 0, 0})).ShouldNot(Succeed())
+	g.Expect((&Subnet{}).UnmarshalBinary([]byte{10, 0, 0, 0, 33})).ShouldNot(Succeed())
+}
+
+func TestCoveringSubnets(t *testing.T) {
+	tests := []struct {
+		description     string
+		start           string
+		end             string
+		prefix          int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "span over three /24s",
+			start:           "10.0.0.100",
+			end:             "10.0.2.50",
+			prefix:          24,
+			expectedSubnets: []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"},
+		},
+		{
+			description:     "span within a single /24",
+			start:           "10.0.0.100",
+			end:             "10.0.0.200",
+			prefix:          24,
+			expectedSubnets: []string{"10.0.0.0/24"},
+		},
+		{
+			description:     "span at the end of the address space",
+			start:           "255.255.255.0",
+			end:             "255.255.255.255",
+			prefix:          25,
+			expectedSubnets: []string{"255.255.255.0/25", "255.255.255.128/25"},
+		},
+		{
+			description: "start after end",
+			start:       "10.0.2.50",
+			end:         "10.0.0.100",
+			prefix:      24,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := CoveringSubnets(tt.start, tt.end, tt.prefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+		})
+	}
+}
+
+func TestSubnetFingerprint(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, errs := ParseAll([]string{"10.0.0.0/24", "10.0.0.5/24", "010.000.000.000/24", "10.0.0.0/25"})
+	g.Expect(errs).To(BeEmpty())
+
+	g.Expect(subnets[0].Fingerprint()).To(HaveLen(16))
+	g.Expect(subnets[0].Fingerprint()).To(Equal(subnets[1].Fingerprint()))
+	g.Expect(subnets[0].Fingerprint()).To(Equal(subnets[2].Fingerprint()))
+	g.Expect(subnets[0].Fingerprint()).NotTo(Equal(subnets[3].Fingerprint()))
+}
+
+func TestRangeToAllowedCIDRs(t *testing.T) {
+	tests := []struct {
+		description     string
+		start           string
+		end             string
+		allowed         []int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "/23 sized range aligned to /24",
+			start:           "10.0.1.0",
+			end:             "10.0.2.255",
+			allowed:         []int{24, 25, 26},
+			expectedSubnets: []string{"10.0.1.0/24", "10.0.2.0/24"},
+		},
+		{
+			description:     "/23 sized range aligned to /26",
+			start:           "10.0.0.64",
+			end:             "10.0.2.63",
+			allowed:         []int{26, 24, 25},
+			expectedSubnets: []string{"10.0.0.64/26", "10.0.0.128/25", "10.0.1.0/24", "10.0.2.0/26"},
+		},
+		{
+			description:     "range not expressible with allowed prefixes",
+			start:           "10.0.0.10",
+			end:             "10.0.0.100",
+			allowed:         []int{25, 26},
+			expectedSubnets: []string{"10.0.0.0/26", "10.0.0.64/26"},
+		},
+		{
+			description: "no allowed prefixes",
+			start:       "10.0.0.0",
+			end:         "10.0.1.255",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := RangeToAllowedCIDRs(tt.start, tt.end, tt.allowed)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+		})
+	}
+}
+
+func TestSubnetAddressType(t *testing.T) {
+	tests := []struct {
+		description         string
+		cidr                string
+		expectedAddressType string
+	}{
+		{
+			description:         "unicast /24",
+			cidr:                "192.168.1.0/24",
+			expectedAddressType: "unicast",
+		},
+		{
+			description:         "multicast /24",
+			cidr:                "239.1.1.0/24",
+			expectedAddressType: "multicast",
+		},
+		{
+			description:         "reserved loopback /16",
+			cidr:                "127.0.0.0/16",
+			expectedAddressType: "reserved",
+		},
+		{
+			description:         "/7 spanning unicast and loopback",
+			cidr:                "126.0.0.0/7",
+			expectedAddressType: "mixed",
+		},
+		{
+			description:         "/3 spanning multicast and reserved",
+			cidr:                "224.0.0.0/3",
+			expectedAddressType: "mixed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.AddressType()).To(Equal(tt.expectedAddressType))
+		})
+	}
+}
+
+func TestNextFreeSubnetOfSize(t *testing.T) {
+	tests := []struct {
+		description    string
+		parent         string
+		used           []string
+		newPrefix      int
+		after          string
+		expectedSubnet string
+		expectedErr    bool
+	}{
+		{
+			description:    "next /26 after 10.0.1.0",
+			parent:         "10.0.0.0/22",
+			used:           []string{"10.0.0.0/24", "10.0.1.64/26"},
+			newPrefix:      26,
+			after:          "10.0.1.0",
+			expectedSubnet: "10.0.1.128/26",
+		},
+		{
+			description:    "after address before parent",
+			parent:         "10.0.0.0/22",
+			used:           []string{"10.0.0.0/24"},
+			newPrefix:      24,
+			after:          "9.255.255.255",
+			expectedSubnet: "10.0.1.0/24",
+		},
+		{
+			description:    "after address within a block",
+			parent:         "10.0.0.0/22",
+			newPrefix:      24,
+			after:          "10.0.1.17",
+			expectedSubnet: "10.0.2.0/24",
+		},
+		{
+			description: "parent exhausted",
+			parent:      "10.0.0.0/22",
+			used:        []string{"10.0.3.0/24"},
+			newPrefix:   24,
+			after:       "10.0.2.0",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := NextFreeSubnetOfSize(tt.parent, tt.used, tt.newPrefix, tt.after)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnet))
+		})
+	}
+}
+
+func TestSubnetHostsHuman(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		hostsNum      int
+		expectedHosts string
+	}{
+		{
+			description:   "/24 subnet",
+			cidr:          "10.0.0.0/24",
+			expectedHosts: "254 hosts",
+		},
+		{
+			description:   "/30 subnet",
+			cidr:          "10.0.0.0/30",
+			expectedHosts: "2 hosts",
+		},
+		{
+			description:   "/32 subnet",
+			cidr:          "10.0.0.1/32",
+			expectedHosts: "1 host",
+		},
+		{
+			description:   "/22 subnet",
+			cidr:          "10.0.0.0/22",
+			expectedHosts: "~1k hosts",
+		},
+		{
+			description:   "/8 subnet",
+			cidr:          "10.0.0.0/8",
+			expectedHosts: "~16.8M hosts",
+		},
+		{
+			description:   "/2 subnet",
+			cidr:          "0.0.0.0/2",
+			expectedHosts: "~1.1G hosts",
+		},
+		{
+			description:   "host count rounded up to the next suffix",
+			hostsNum:      999950,
+			expectedHosts: "~1M hosts",
+		},
+		{
+			description:   "host count rounded down within the suffix",
+			hostsNum:      999949,
+			expectedHosts: "~999.9k hosts",
+		},
+		{
+			description:   "IPv6 /64 subnet",
+			cidr:          "2001:db8::/64",
+			expectedHosts: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet := &Subnet{HostsNum: tt.hostsNum}
+			if tt.cidr != "" {
+				var err error
+				subnet, err = CalculateSubnet(tt.cidr)
+				g.Expect(err).ShouldNot(HaveOccurred())
+			}
+			g.Expect(subnet.HostsHuman()).To(Equal(tt.expectedHosts))
+		})
+	}
+}
+
+func TestAllSameSize(t *testing.T) {
+	tests := []struct {
+		description    string
+		cidrs          []string
+		expectedSame   bool
+		expectedPrefix int
+	}{
+		{
+			description:    "uniform /24 subnets",
+			cidrs:          []string{"10.0.0.0/24", "10.0.1.0/24", "192.168.0.0/24"},
+			expectedSame:   true,
+			expectedPrefix: 24,
+		},
+		{
+			description: "mixed subnets",
+			cidrs:       []string{"10.0.0.0/24", "10.0.1.0/25"},
+		},
+		{
+			description: "no subnets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, errs := ParseAll(tt.cidrs)
+			g.Expect(errs).To(BeEmpty())
+			same, prefix := AllSameSize(subnets)
+			g.Expect(same).To(Equal(tt.expectedSame))
+			g.Expect(prefix).To(BeIdenticalTo(tt.expectedPrefix))
+		})
+	}
+}
+
+func TestSubnetGridPosition(t *testing.T) {
+	tests := []struct {
+		description  string
+		cidr         string
+		parentPrefix int
+		expectedRow  int
+		expectedCol  int
+		expectedErr  bool
+	}{
+		{
+			description:  "/24 in /16",
+			cidr:         "10.0.17.0/24",
+			parentPrefix: 16,
+			expectedRow:  1,
+			expectedCol:  1,
+		},
+		{
+			description:  "last /24 in /16",
+			cidr:         "10.0.255.0/24",
+			parentPrefix: 16,
+			expectedRow:  15,
+			expectedCol:  15,
+		},
+		{
+			description:  "/26 in /23",
+			cidr:         "10.0.1.64/26",
+			parentPrefix: 23,
+			expectedRow:  1,
+			expectedCol:  1,
+		},
+		{
+			description:  "parent smaller than subnet",
+			cidr:         "10.0.17.0/24",
+			parentPrefix: 25,
+			expectedErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			row, col, err := subnet.GridPosition(tt.parentPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(row).To(BeIdenticalTo(tt.expectedRow))
+			g.Expect(col).To(BeIdenticalTo(tt.expectedCol))
+		})
+	}
+}
+
+func TestAllocateVLSMPacked(t *testing.T) {
+	tests := []struct {
+		description     string
+		parent          string
+		requests        []int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "mixed requests packed into a /24",
+			parent:          "10.0.0.0/24",
+			requests:        []int{10, 100, 20, 50},
+			expectedSubnets: []string{"10.0.0.0/28", "10.0.0.128/25", "10.0.0.32/27", "10.0.0.64/26"},
+		},
+		{
+			description:     "small requests reuse split blocks",
+			parent:          "10.0.0.0/24",
+			requests:        []int{2, 2, 2, 2},
+			expectedSubnets: []string{"10.0.0.0/30", "10.0.0.4/30", "10.0.0.8/30", "10.0.0.12/30"},
+		},
+		{
+			description: "requests exceed the parent",
+			parent:      "10.0.0.0/24",
+			requests:    []int{100, 100, 100},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := AllocateVLSMPacked(tt.parent, tt.requests)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+				g.Expect(subnet.HostsNum).To(BeNumerically(">=", tt.requests[i]))
+			}
+		})
+	}
+}
+
+func TestAllocateVLSMPackedFragmentation(t *testing.T) {
+	g := NewWithT(t)
+
+	parent := "10.0.0.0/23"
+	parentNet, err := CalculateSubnet(parent)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	parentFirst, parentLast := parentNet.addressRange()
+	// freeBlocks returns the CIDR blocks of the parent left free by the subnets.
+	freeBlocks := func(subnets []*Subnet) []string {
+		var blocks []string
+		for _, r := range subtractAddressRanges(ipRange{first: parentFirst, last: parentLast}, mergeAddressRanges(subnets)) {
+			free, err := rangeToCIDRs(r.first, r.last)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			for _, block := range free {
+				blocks = append(blocks, block.NetworkCIDR)
+			}
+		}
+		return blocks
+	}
+
+	requests := []int{10, 100, 20, 50}
+	packed, err := AllocateVLSMPacked(parent, requests)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	largestFirst, err := AllocateVLSM(parent, requests)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Best fit in request order fragments the parent no more than the naive
+	// largest-first allocation, leaving the same number and sizes of free blocks.
+	g.Expect(freeBlocks(packed)).To(Equal([]string{"10.0.0.16/28", "10.0.1.0/24"}))
+	g.Expect(freeBlocks(largestFirst)).To(Equal([]string{"10.0.0.240/28", "10.0.1.0/24"}))
+}
+
+func TestCanWidenTo(t *testing.T) {
+	tests := []struct {
+		description string
+		subnet      string
+		prefix      int
+		used        []string
+		expectedOK  bool
+		expectedErr bool
+	}{
+		{
+			description: "/25 to /24 collides with used sibling",
+			subnet:      "10.0.0.0/25",
+			prefix:      24,
+			used:        []string{"10.0.0.0/25", "10.0.0.128/25"},
+			expectedOK:  false,
+		},
+		{
+			description: "/25 to /24 with free sibling",
+			subnet:      "10.0.0.0/25",
+			prefix:      24,
+			used:        []string{"10.0.0.0/25", "10.0.0.0/26", "10.0.1.0/24"},
+			expectedOK:  true,
+		},
+		{
+			description: "/25 to /23 collides with used block",
+			subnet:      "10.0.0.128/25",
+			prefix:      23,
+			used:        []string{"10.0.1.64/26"},
+			expectedOK:  false,
+		},
+		{
+			description: "prefix longer than subnet",
+			subnet:      "10.0.0.0/25",
+			prefix:      26,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ok, err := CanWidenTo(tt.subnet, tt.prefix, tt.used)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(ok).To(Equal(tt.expectedOK))
+		})
+	}
+}
+
+func TestMapVLANsToSubnets(t *testing.T) {
+	tests := []struct {
+		description     string
+		parent          string
+		childPrefix     int
+		vlanStart       int
+		expectedSubnets map[int]string
+		expectedErr     bool
+	}{
+		{
+			description: "VLANs 100+ to /26 children of a /24",
+			parent:      "10.0.0.0/24",
+			childPrefix: 26,
+			vlanStart:   100,
+			expectedSubnets: map[int]string{
+				100: "10.0.0.0/26",
+				101: "10.0.0.64/26",
+				102: "10.0.0.128/26",
+				103: "10.0.0.192/26",
+			},
+		},
+		{
+			description: "VLAN IDs exceed 4094",
+			parent:      "10.0.0.0/24",
+			childPrefix: 26,
+			vlanStart:   4092,
+			expectedErr: true,
+		},
+		{
+			description: "VLAN 0 is reserved",
+			parent:      "10.0.0.0/24",
+			childPrefix: 26,
+			vlanStart:   0,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := MapVLANsToSubnets(tt.parent, tt.childPrefix, tt.vlanStart)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for vlan, cidr := range tt.expectedSubnets {
+				g.Expect(subnets).To(HaveKey(vlan))
+				g.Expect(subnets[vlan].NetworkCIDR).To(Equal(cidr))
+			}
+		})
+	}
+}
+
+func TestComplementWithin(t *testing.T) {
+	tests := []struct {
+		description        string
+		subnet             string
+		parent             string
+		expectedComplement []string
+		expectedErr        bool
+	}{
+		{
+			description:        "/24 within /22",
+			subnet:             "10.0.1.0/24",
+			parent:             "10.0.0.0/22",
+			expectedComplement: []string{"10.0.0.0/24", "10.0.2.0/23"},
+		},
+		{
+			description:        "/26 at the end of a /24",
+			subnet:             "10.0.0.192/26",
+			parent:             "10.0.0.0/24",
+			expectedComplement: []string{"10.0.0.0/25", "10.0.0.128/26"},
+		},
+		{
+			description: "subnet equals parent",
+			subnet:      "10.0.0.0/24",
+			parent:      "10.0.0.0/24",
+		},
+		{
+			description: "subnet outside the parent",
+			subnet:      "10.0.4.0/24",
+			parent:      "10.0.0.0/22",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			complement, err := ComplementWithin(tt.subnet, tt.parent)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(complement).To(HaveLen(len(tt.expectedComplement)))
+			for i, subnet := range complement {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedComplement[i]))
+			}
+		})
+	}
+}
+
+func TestSubnetMeetsMinimumSize(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		minPrefix     int
+		expectedMeets bool
+	}{
+		{
+			description:   "/28 against minimum /27",
+			cidr:          "10.0.0.0/28",
+			minPrefix:     27,
+			expectedMeets: false,
+		},
+		{
+			description:   "/27 against minimum /27",
+			cidr:          "10.0.0.0/27",
+			minPrefix:     27,
+			expectedMeets: true,
+		},
+		{
+			description:   "/24 against minimum /27",
+			cidr:          "10.0.0.0/24",
+			minPrefix:     27,
+			expectedMeets: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.MeetsMinimumSize(tt.minPrefix)).To(Equal(tt.expectedMeets))
+		})
+	}
+}
+
+func TestUniformPrefixFor(t *testing.T) {
+	tests := []struct {
+		description    string
+		counts         []int
+		expectedPrefix int
+		expectedErr    bool
+	}{
+		{
+			description:    "sized for 200 hosts",
+			counts:         []int{100, 200, 50},
+			expectedPrefix: 24,
+		},
+		{
+			description:    "sized for 30 hosts",
+			counts:         []int{30, 2},
+			expectedPrefix: 27,
+		},
+		{
+			description: "no host counts",
+			expectedErr: true,
+		},
+		{
+			description: "invalid host count",
+			counts:      []int{0},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			prefix, err := UniformPrefixFor(tt.counts)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(prefix).To(BeIdenticalTo(tt.expectedPrefix))
+		})
+	}
+}
+
+func TestSubnetZeroAndAllOnesSubnet(t *testing.T) {
+	tests := []struct {
+		description     string
+		cidr            string
+		parentPrefix    int
+		expectedZero    bool
+		expectedAllOnes bool
+	}{
+		{
+			description:  "first /26 of a /24",
+			cidr:         "10.0.0.0/26",
+			parentPrefix: 24,
+			expectedZero: true,
+		},
+		{
+			description:     "last /26 of a /24",
+			cidr:            "10.0.0.192/26",
+			parentPrefix:    24,
+			expectedAllOnes: true,
+		},
+		{
+			description:  "second /26 of a /24",
+			cidr:         "10.0.0.64/26",
+			parentPrefix: 24,
+		},
+		{
+			description:  "parent prefix not shorter than subnet",
+			cidr:         "10.0.0.0/24",
+			parentPrefix: 24,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.IsSubnetZero(tt.parentPrefix)).To(Equal(tt.expectedZero))
+			g.Expect(subnet.IsAllOnesSubnet(tt.parentPrefix)).To(Equal(tt.expectedAllOnes))
+		})
+	}
+}
+
+func TestUtilizationDelta(t *testing.T) {
+	tests := []struct {
+		description      string
+		before           []string
+		after            []string
+		parent           string
+		expectedFreed    int64
+		expectedConsumed int64
+		expectedErr      bool
+	}{
+		{
+			description:      "one /24 freed and two /25 added",
+			before:           []string{"10.0.0.0/24", "10.0.1.0/24"},
+			after:            []string{"10.0.1.0/24", "10.0.2.0/25", "10.0.3.128/25"},
+			parent:           "10.0.0.0/22",
+			expectedFreed:    256,
+			expectedConsumed: 256,
+		},
+		{
+			description:      "/24 split into overlapping /25s",
+			before:           []string{"10.0.0.0/24"},
+			after:            []string{"10.0.0.0/25", "10.0.0.0/26"},
+			parent:           "10.0.0.0/22",
+			expectedFreed:    128,
+			expectedConsumed: 0,
+		},
+		{
+			description: "subnet outside the parent",
+			before:      []string{"10.0.0.0/24"},
+			after:       []string{"10.0.4.0/24"},
+			parent:      "10.0.0.0/22",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			freed, consumed, err := UtilizationDelta(tt.before, tt.after, tt.parent)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(freed).To(BeIdenticalTo(tt.expectedFreed))
+			g.Expect(consumed).To(BeIdenticalTo(tt.expectedConsumed))
+		})
+	}
+}
+
+func TestFilterHosts(t *testing.T) {
+	g := NewWithT(t)
+
+	IPs, err := FilterHosts("10.0.0.0/28", func(ip net.IP) bool {
+		return ip.To4()[3]%2 == 0
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	expected := []string{"10.0.0.2", "10.0.0.4", "10.0.0.6", "10.0.0.8", "10.0.0.10", "10.0.0.12", "10.0.0.14"}
+	g.Expect(IPs).To(HaveLen(len(expected)))
+	for i, ip := range IPs {
+		g.Expect(ip.String()).To(Equal(expected[i]))
+	}
+
+	_, err = FilterHosts("10.0.0.0/33", func(net.IP) bool { return true })
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestSeparatingPrefix(t *testing.T) {
+	tests := []struct {
+		description    string
+		a              string
+		b              string
+		expectedPrefix int
+		expectedErr    bool
+	}{
+		{
+			description:    "addresses differing in the 17th bit",
+			a:              "10.0.0.0",
+			b:              "10.0.128.0",
+			expectedPrefix: 17,
+		},
+		{
+			description:    "addresses differing in the last bit",
+			a:              "10.0.0.1",
+			b:              "10.0.0.0",
+			expectedPrefix: 32,
+		},
+		{
+			description:    "addresses differing in the first bit",
+			a:              "10.0.0.0",
+			b:              "192.168.0.0",
+			expectedPrefix: 1,
+		},
+		{
+			description: "identical addresses",
+			a:           "10.0.0.1",
+			b:           "10.0.0.1",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			prefix, err := SeparatingPrefix(tt.a, tt.b)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(prefix).To(BeIdenticalTo(tt.expectedPrefix))
+		})
+	}
+}
+
+func TestContainedInAny(t *testing.T) {
+	tests := []struct {
+		description       string
+		subnet            string
+		allowlist         []string
+		expectedContained bool
+		expectedEntry     string
+		expectedErr       bool
+	}{
+		{
+			description:       "/26 contained in a /24",
+			subnet:            "10.0.1.64/26",
+			allowlist:         []string{"10.0.0.0/24", "10.0.1.0/24"},
+			expectedContained: true,
+			expectedEntry:     "10.0.1.0/24",
+		},
+		{
+			description: "/23 only partially contained",
+			subnet:      "10.0.0.0/23",
+			allowlist:   []string{"10.0.0.0/24", "10.0.1.0/24"},
+		},
+		{
+			description: "invalid allowlist entry",
+			subnet:      "10.0.1.64/26",
+			allowlist:   []string{"10.0.0.0/24", "10.0.1.0"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			contained, entry, err := ContainedInAny(tt.subnet, tt.allowlist)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(contained).To(Equal(tt.expectedContained))
+			if !tt.expectedContained {
+				g.Expect(entry).To(BeNil())
+				return
+			}
+			g.Expect(entry.NetworkCIDR).To(Equal(tt.expectedEntry))
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		description     string
+		spec            PlanSpec
+		expectedSubnets map[string]string
+		expectedErr     bool
+	}{
+		{
+			description: "hosts and prefix requirements",
+			spec: PlanSpec{
+				Parent: "10.0.0.0/24",
+				Requirements: []PlanRequirement{
+					{Name: "mgmt", Hosts: 10},
+					{Name: "web", Hosts: 100},
+					{Name: "transfer", Prefix: 30},
+					{Name: "db", Prefix: 26},
+				},
+			},
+			expectedSubnets: map[string]string{
+				"web":      "10.0.0.0/25",
+				"db":       "10.0.0.128/26",
+				"mgmt":     "10.0.0.192/28",
+				"transfer": "10.0.0.208/30",
+			},
+		},
+		{
+			description: "requirements exceed the parent",
+			spec: PlanSpec{
+				Parent: "10.0.0.0/24",
+				Requirements: []PlanRequirement{
+					{Name: "web", Prefix: 24},
+					{Name: "db", Prefix: 30},
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			description: "hosts and prefix in one requirement",
+			spec: PlanSpec{
+				Parent: "10.0.0.0/24",
+				Requirements: []PlanRequirement{
+					{Name: "web", Hosts: 10, Prefix: 28},
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			description: "duplicate names",
+			spec: PlanSpec{
+				Parent: "10.0.0.0/24",
+				Requirements: []PlanRequirement{
+					{Name: "web", Hosts: 10},
+					{Name: "web", Hosts: 10},
+				},
+			},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := Plan(tt.spec)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.spec.Requirements)))
+			for i, requirement := range tt.spec.Requirements {
+				g.Expect(subnets[i].NetworkCIDR).To(Equal(tt.expectedSubnets[requirement.Name]))
+				g.Expect(subnets[i].HostsNum).To(BeNumerically(">=", requirement.Hosts))
+			}
+		})
+	}
+}
+
+func TestSubnetMidpoint(t *testing.T) {
+	tests := []struct {
+		description      string
+		cidr             string
+		expectedMidpoint string
+	}{
+		{
+			description:      "/24 subnet",
+			cidr:             "10.0.0.0/24",
+			expectedMidpoint: "10.0.0.128",
+		},
+		{
+			description:      "/22 subnet",
+			cidr:             "10.0.4.0/22",
+			expectedMidpoint: "10.0.6.0",
+		},
+		{
+			description:      "/0 subnet",
+			cidr:             "0.0.0.0/0",
+			expectedMidpoint: "128.0.0.0",
+		},
+		{
+			description:      "/32 subnet",
+			cidr:             "10.0.0.5/32",
+			expectedMidpoint: "10.0.0.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.Midpoint().String()).To(Equal(tt.expectedMidpoint))
+		})
+	}
+}
+
+func TestCalculateSubnetIPv6(t *testing.T) {
+	tests := []struct {
+		description           string
+		cidr                  string
+		expectedBroadcastIP   string
+		expectedHostMinIP     string
+		expectedHostMaxIP     string
+		expectedHostsNum      int
+		expectedTotalHostsNum int
+	}{
+		{
+			description:           "/127 point-to-point subnet",
+			cidr:                  "2001:db8::/127",
+			expectedBroadcastIP:   "2001:db8::1",
+			expectedHostMinIP:     "2001:db8::",
+			expectedHostMaxIP:     "2001:db8::1",
+			expectedHostsNum:      2,
+			expectedTotalHostsNum: 2,
+		},
+		{
+			description:           "/128 single host subnet",
+			cidr:                  "2001:db8::5/128",
+			expectedBroadcastIP:   "2001:db8::5",
+			expectedHostMinIP:     "2001:db8::5",
+			expectedHostMaxIP:     "2001:db8::5",
+			expectedHostsNum:      1,
+			expectedTotalHostsNum: 1,
+		},
+		{
+			description:           "/120 subnet",
+			cidr:                  "2001:db8::1:0/120",
+			expectedBroadcastIP:   "2001:db8::1:ff",
+			expectedHostMinIP:     "2001:db8::1:0",
+			expectedHostMaxIP:     "2001:db8::1:ff",
+			expectedHostsNum:      256,
+			expectedTotalHostsNum: 256,
+		},
+		{
+			description:           "/32 subnet exceeding int",
+			cidr:                  "2001:db8::/32",
+			expectedBroadcastIP:   "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff",
+			expectedHostMinIP:     "2001:db8::",
+			expectedHostMaxIP:     "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff",
+			expectedHostsNum:      math.MaxInt,
+			expectedTotalHostsNum: math.MaxInt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.BroadcastIP.String()).To(Equal(tt.expectedBroadcastIP))
+			g.Expect(subnet.HostMinIP.String()).To(Equal(tt.expectedHostMinIP))
+			g.Expect(subnet.HostMaxIP.String()).To(Equal(tt.expectedHostMaxIP))
+			g.Expect(subnet.HostsNum).To(BeIdenticalTo(tt.expectedHostsNum))
+			g.Expect(subnet.TotalHostsNum).To(BeIdenticalTo(tt.expectedTotalHostsNum))
+		})
+	}
+}
+
+func TestFindAllOverlaps(t *testing.T) {
+	g := NewWithT(t)
+
+	cidrs := []string{"10.0.0.0/16", "192.168.0.0/24"}
+	for i := 0; i < 64; i++ {
+		cidrs = append(cidrs, fmt.Sprintf("172.16.%d.0/24", i))
+	}
+	cidrs = append(cidrs, "10.0.1.0/24", "172.16.10.128/25", "10.0.1.64/26")
+
+	overlaps, err := FindAllOverlaps(cidrs)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(overlaps).To(Equal([][2]string{
+		{"10.0.0.0/16", "10.0.1.0/24"},
+		{"10.0.0.0/16", "10.0.1.64/26"},
+		{"10.0.1.0/24", "10.0.1.64/26"},
+		{"172.16.10.0/24", "172.16.10.128/25"},
+	}))
+
+	_, err = FindAllOverlaps([]string{"10.0.0.0/16", "10.0.0.0"})
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func BenchmarkFindAllOverlaps(b *testing.B) {
+	cidrs := make([]string, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		cidrs = append(cidrs, fmt.Sprintf("10.%d.%d.0/24", i/256, i%256))
+	}
+	cidrs = append(cidrs, "10.0.0.0/20", "10.20.0.0/16")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := FindAllOverlaps(cidrs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSubnetIPNet(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("10.0.0.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	ipNet := subnet.IPNet()
+	g.Expect(ipNet.String()).To(Equal("10.0.0.0/24"))
+
+	ipNet.IP[0] = 192
+	ipNet.Mask[3] = 0xFF
+	g.Expect(subnet.Network.String()).To(Equal("10.0.0.0/24"))
+	g.Expect(subnet.NetworkMask.String()).To(Equal("ffffff00"))
+}
+
+func TestIPv4OnlyCalculationsRejectIPv6(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(func() {
+		_, err := CalculateSubnet("2001:db8::/32")
+		g.Expect(err).ShouldNot(HaveOccurred())
+	}).NotTo(Panic())
+	_, err := CalculateSubnet("10.0.0/24")
+	g.Expect(err).Should(HaveOccurred())
+
+	_, err = CalculateSubnetsByCIDR("2001:db8::/32", 48)
+	g.Expect(err).To(MatchError(ContainSubstring("is not a valid IPv4 address")))
+	_, err = GetHostIPsForSubnet("2001:db8::/120")
+	g.Expect(err).To(MatchError(ContainSubstring("is not a valid IPv4 address")))
+	_, err = FindAllOverlaps([]string{"10.0.0.0/24", "2001:db8::/32"})
+	g.Expect(err).To(MatchError(ContainSubstring("is not a valid IPv4 address")))
+
+	subnet, err := CalculateSubnet("2001:db8::/32")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	_, err = CalculateSubnets(subnet, net.CIDRMask(48, 128), 1<<16)
+	g.Expect(err).To(MatchError(ContainSubstring("is not a valid IPv4 address")))
+	_, _, err = subnet.Widen()
+	g.Expect(err).To(MatchError(ContainSubstring("is not a valid IPv4 address")))
+
+	sibling, err := CalculateSubnet("2001:db9::/32")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.ID()).To(BeZero())
+	aggregatable, supernet := CanAggregate(subnet, sibling)
+	g.Expect(aggregatable).To(BeFalse())
+	g.Expect(supernet).To(BeNil())
+	g.Expect(subnet.HostForKey("key")).To(BeNil())
+	first, last := subnet.HostSample(2)
+	g.Expect(first).To(BeEmpty())
+	g.Expect(last).To(BeEmpty())
+	g.Expect(subnet.AddressType()).To(BeEmpty())
+	g.Expect(subnet.Midpoint()).To(BeNil())
+	g.Expect(subnet.IsSubnetZero(16)).To(BeFalse())
+	g.Expect(subnet.IsAllOnesSubnet(16)).To(BeFalse())
+	_, err = subnet.HostOffset(net.ParseIP("2001:db8::1"))
+	g.Expect(err).To(MatchError(ContainSubstring("is not a valid IPv4 address")))
+	g.Expect(subnet.DetailedString()).To(BeEmpty())
+}
+
+func TestCalculateSubnetsInRange(t *testing.T) {
+	tests := []struct {
+		description     string
+		start           string
+		end             string
+		childPrefix     int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "four /24s in 10.0.0.0-10.0.3.255",
+			start:           "10.0.0.0",
+			end:             "10.0.3.255",
+			childPrefix:     24,
+			expectedSubnets: []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
+		},
+		{
+			description:     "partial blocks at both ends are skipped",
+			start:           "10.0.0.1",
+			end:             "10.0.3.254",
+			childPrefix:     24,
+			expectedSubnets: []string{"10.0.1.0/24", "10.0.2.0/24"},
+		},
+		{
+			description: "range too small for a single block",
+			start:       "10.0.0.10",
+			end:         "10.0.0.20",
+			childPrefix: 24,
+		},
+		{
+			description: "start after end",
+			start:       "10.0.3.255",
+			end:         "10.0.0.0",
+			childPrefix: 24,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := CalculateSubnetsInRange(tt.start, tt.end, tt.childPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+		})
+	}
+}
+
+func TestCalculateSubnetPointToPointAndSingleHost(t *testing.T) {
+	tests := []struct {
+		description           string
+		cidr                  string
+		expectedHostMinIP     string
+		expectedHostMaxIP     string
+		expectedHostsNum      int
+		expectedTotalHostsNum int
+	}{
+		{
+			description:           "/31 point-to-point subnet",
+			cidr:                  "10.0.0.0/31",
+			expectedHostMinIP:     "10.0.0.0",
+			expectedHostMaxIP:     "10.0.0.1",
+			expectedHostsNum:      2,
+			expectedTotalHostsNum: 2,
+		},
+		{
+			description:           "/31 given by its upper address",
+			cidr:                  "10.0.0.1/31",
+			expectedHostMinIP:     "10.0.0.0",
+			expectedHostMaxIP:     "10.0.0.1",
+			expectedHostsNum:      2,
+			expectedTotalHostsNum: 2,
+		},
+		{
+			description:           "/32 single host subnet",
+			cidr:                  "10.0.0.5/32",
+			expectedHostMinIP:     "10.0.0.5",
+			expectedHostMaxIP:     "10.0.0.5",
+			expectedHostsNum:      1,
+			expectedTotalHostsNum: 1,
+		},
+		{
+			description:           "last /31 of the address space",
+			cidr:                  "255.255.255.254/31",
+			expectedHostMinIP:     "255.255.255.254",
+			expectedHostMaxIP:     "255.255.255.255",
+			expectedHostsNum:      2,
+			expectedTotalHostsNum: 2,
+		},
+		{
+			description:           "last /32 of the address space",
+			cidr:                  "255.255.255.255/32",
+			expectedHostMinIP:     "255.255.255.255",
+			expectedHostMaxIP:     "255.255.255.255",
+			expectedHostsNum:      1,
+			expectedTotalHostsNum: 1,
+		},
+		{
+			description:           "/30 subnet",
+			cidr:                  "10.0.0.4/30",
+			expectedHostMinIP:     "10.0.0.5",
+			expectedHostMaxIP:     "10.0.0.6",
+			expectedHostsNum:      2,
+			expectedTotalHostsNum: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.HostMinIP.String()).To(Equal(tt.expectedHostMinIP))
+			g.Expect(subnet.HostMaxIP.String()).To(Equal(tt.expectedHostMaxIP))
+			g.Expect(subnet.HostsNum).To(BeIdenticalTo(tt.expectedHostsNum))
+			g.Expect(subnet.TotalHostsNum).To(BeIdenticalTo(tt.expectedTotalHostsNum))
+
+			IPs, err := GetHostIPsForSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(IPs).To(HaveLen(tt.expectedHostsNum))
+			g.Expect(IPs[0].String()).To(Equal(tt.expectedHostMinIP))
+			g.Expect(IPs[len(IPs)-1].String()).To(Equal(tt.expectedHostMaxIP))
+
+			reversedIPs, err := GetHostIPsForSubnetReversed(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(reversedIPs).To(HaveLen(tt.expectedHostsNum))
+			g.Expect(reversedIPs[0].String()).To(Equal(tt.expectedHostMaxIP))
+		})
+	}
+}
+
+func TestSubnetJSON(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("192.168.1.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	data, err := json.Marshal(subnet)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal(`{"network_cidr":"192.168.1.0/24","network":"192.168.1.0/24","ip":"192.168.1.0",` +
+		`"netmask":"255.255.255.0","broadcast":"192.168.1.255","host_min":"192.168.1.1","host_max":"192.168.1.254",` +
+		`"hosts":254,"total_hosts":256}`))
+
+	decoded := &Subnet{}
+	g.Expect(json.Unmarshal(data, decoded)).To(Succeed())
+	g.Expect(decoded).To(Equal(subnet))
+
+	g.Expect(json.Unmarshal([]byte(`{"network_cidr":"192.168.1.0/33"}`), &Subnet{})).ShouldNot(Succeed())
+}
+
+func TestSubnetContains(t *testing.T) {
+	tests := []struct {
+		description      string
+		cidr             string
+		ip               net.IP
+		expectedContains bool
+	}{
+		{
+			description:      "host address",
+			cidr:             "10.0.0.0/24",
+			ip:               net.ParseIP("10.0.0.5"),
+			expectedContains: true,
+		},
+		{
+			description:      "host address in 4-byte form",
+			cidr:             "10.0.0.0/24",
+			ip:               net.ParseIP("10.0.0.5").To4(),
+			expectedContains: true,
+		},
+		{
+			description:      "network and broadcast address",
+			cidr:             "10.0.0.0/24",
+			ip:               net.ParseIP("10.0.0.255"),
+			expectedContains: true,
+		},
+		{
+			description:      "address outside of subnet",
+			cidr:             "10.0.0.0/24",
+			ip:               net.ParseIP("10.0.1.5"),
+			expectedContains: false,
+		},
+		{
+			description:      "IPv6 address",
+			cidr:             "2001:db8::/32",
+			ip:               net.ParseIP("2001:db8::1"),
+			expectedContains: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.Contains(tt.ip)).To(Equal(tt.expectedContains))
+		})
+	}
+}
+
+func TestCalculateSubnetsWithStride(t *testing.T) {
+	tests := []struct {
+		description     string
+		parent          string
+		childPrefix     int
+		stride          int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "/24 to /26 with stride 2",
+			parent:          "10.0.0.0/24",
+			childPrefix:     26,
+			stride:          2,
+			expectedSubnets: []string{"10.0.0.0/26", "10.0.0.128/26"},
+		},
+		{
+			description:     "/24 to /27 with stride 3",
+			parent:          "10.0.0.0/24",
+			childPrefix:     27,
+			stride:          3,
+			expectedSubnets: []string{"10.0.0.0/27", "10.0.0.96/27", "10.0.0.192/27"},
+		},
+		{
+			description: "invalid stride",
+			parent:      "10.0.0.0/24",
+			childPrefix: 26,
+			stride:      0,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := CalculateSubnetsWithStride(tt.parent, tt.childPrefix, tt.stride)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+		})
+	}
+}
+
+func TestCanDelete(t *testing.T) {
+	tests := []struct {
+		description      string
+		subnet           string
+		children         []string
+		expectedOK       bool
+		expectedBlockers []string
+		expectedErr      bool
+	}{
+		{
+			description:      "subnet with two child allocations",
+			subnet:           "10.0.0.0/24",
+			children:         []string{"10.0.0.0/26", "10.0.1.0/26", "10.0.0.128/26"},
+			expectedBlockers: []string{"10.0.0.0/26", "10.0.0.128/26"},
+		},
+		{
+			description: "subnet without child allocations",
+			subnet:      "10.0.0.0/24",
+			children:    []string{"10.0.1.0/26"},
+			expectedOK:  true,
+		},
+		{
+			description: "invalid child",
+			subnet:      "10.0.0.0/24",
+			children:    []string{"10.0.1.0/33"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ok, blockers, err := CanDelete(tt.subnet, tt.children)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(ok).To(Equal(tt.expectedOK))
+			g.Expect(blockers).To(Equal(tt.expectedBlockers))
+		})
+	}
+}
+
+func TestSubnetHostIPs(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("10.0.0.16/28")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	var IPs []string
+	for ip := range subnet.HostIPs() {
+		IPs = append(IPs, ip.String())
+	}
+	g.Expect(IPs).To(HaveLen(14))
+	g.Expect(IPs[0]).To(Equal("10.0.0.17"))
+	g.Expect(IPs[13]).To(Equal("10.0.0.30"))
+
+	IPs = nil
+	err = ForEachHostIP("10.0.0.16/28", func(ip net.IP) bool {
+		IPs = append(IPs, ip.String())
+		return len(IPs) < 3
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(IPs).To(Equal([]string{"10.0.0.17", "10.0.0.18", "10.0.0.19"}))
+
+	IPs = nil
+	err = ForEachHostIP("2001:db8::/126", func(ip net.IP) bool {
+		IPs = append(IPs, ip.String())
+		return true
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(IPs).To(Equal([]string{"2001:db8::", "2001:db8::1", "2001:db8::2", "2001:db8::3"}))
+
+	g.Expect(ForEachHostIP("10.0.0.16/33", func(net.IP) bool { return true })).ShouldNot(Succeed())
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		description        string
+		cidrs              []string
+		expectedAggregated []string
+		expectedErr        bool
+	}{
+		{
+			description:        "two aligned /24s",
+			cidrs:              []string{"192.168.1.0/24", "192.168.0.0/24"},
+			expectedAggregated: []string{"192.168.0.0/23"},
+		},
+		{
+			description:        "non-adjacent /24s",
+			cidrs:              []string{"192.168.0.0/24", "192.168.2.0/24"},
+			expectedAggregated: []string{"192.168.0.0/24", "192.168.2.0/24"},
+		},
+		{
+			description:        "adjacent but not aligned /24s",
+			cidrs:              []string{"192.168.2.0/24", "192.168.1.0/24"},
+			expectedAggregated: []string{"192.168.1.0/24", "192.168.2.0/24"},
+		},
+		{
+			description:        "overlapping and adjacent blocks",
+			cidrs:              []string{"10.0.0.0/24", "10.0.0.128/25", "10.0.1.0/25", "10.0.1.128/25", "10.0.2.0/23"},
+			expectedAggregated: []string{"10.0.0.0/22"},
+		},
+		{
+			description: "invalid block",
+			cidrs:       []string{"10.0.0.0/24", "10.0.1.0"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			aggregated, err := Aggregate(tt.cidrs)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(aggregated).To(Equal(tt.expectedAggregated))
+		})
+	}
+}
+
+func TestHostRoutes(t *testing.T) {
+	g := NewWithT(t)
+
+	routes, err := HostRoutes("10.0.0.4/30")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(routes).To(HaveLen(2))
+	g.Expect(routes[0].NetworkCIDR).To(Equal("10.0.0.5/32"))
+	g.Expect(routes[1].NetworkCIDR).To(Equal("10.0.0.6/32"))
+	for _, route := range routes {
+		g.Expect(route.TotalHostsNum).To(BeIdenticalTo(1))
+	}
+
+	_, err = HostRoutes("10.0.0.4")
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestSubnetHostBitsUsed(t *testing.T) {
+	tests := []struct {
+		description  string
+		cidr         string
+		maxHost      int
+		expectedBits int
+	}{
+		{
+			description:  "100 hosts in a /24",
+			cidr:         "10.0.0.0/24",
+			maxHost:      100,
+			expectedBits: 7,
+		},
+		{
+			description:  "128 hosts in a /24",
+			cidr:         "10.0.0.0/24",
+			maxHost:      128,
+			expectedBits: 8,
+		},
+		{
+			description:  "bounded by the host bits of a /28",
+			cidr:         "10.0.0.0/28",
+			maxHost:      100,
+			expectedBits: 4,
+		},
+		{
+			description:  "no hosts",
+			cidr:         "10.0.0.0/24",
+			maxHost:      0,
+			expectedBits: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.HostBitsUsed(tt.maxHost)).To(BeIdenticalTo(tt.expectedBits))
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		description      string
+		a                string
+		b                string
+		expectedOverlaps bool
+		expectedErr      bool
+	}{
+		{
+			description:      "/24 containing a /25",
+			a:                "10.0.0.0/24",
+			b:                "10.0.0.128/25",
+			expectedOverlaps: true,
+		},
+		{
+			description:      "/25 contained in a /24",
+			a:                "10.0.0.128/25",
+			b:                "10.0.0.0/24",
+			expectedOverlaps: true,
+		},
+		{
+			description:      "adjacent /24s",
+			a:                "10.0.0.0/24",
+			b:                "10.0.1.0/24",
+			expectedOverlaps: false,
+		},
+		{
+			description:      "IPv6 blocks",
+			a:                "2001:db8::/32",
+			b:                "2001:db8:1::/48",
+			expectedOverlaps: true,
+		},
+		{
+			description: "invalid CIDR",
+			a:           "10.0.0.0/24",
+			b:           "10.0.1.0",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			overlaps, err := Overlaps(tt.a, tt.b)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(overlaps).To(Equal(tt.expectedOverlaps))
+		})
+	}
+}
+
+func TestCalculateSubnetsByCIDRExcluding(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, err := CalculateSubnetsByCIDRExcluding("10.0.0.0/16", 24, []string{"10.0.5.0/24", "10.0.7.128/25"})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnets).To(HaveLen(254))
+	for _, subnet := range subnets {
+		g.Expect(subnet.NetworkCIDR).NotTo(BeElementOf("10.0.5.0/24", "10.0.7.0/24"))
+	}
+	g.Expect(subnets[5].NetworkCIDR).To(Equal("10.0.6.0/24"))
+	g.Expect(subnets[6].NetworkCIDR).To(Equal("10.0.8.0/24"))
+
+	_, err = CalculateSubnetsByCIDRExcluding("10.0.0.0/16", 24, []string{"10.0.5.0"})
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestAllocateVLSM(t *testing.T) {
+	tests := []struct {
+		description     string
+		cidr            string
+		hostCounts      []int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "mixed host counts in a /24",
+			cidr:            "192.168.0.0/24",
+			hostCounts:      []int{10, 100, 2, 50},
+			expectedSubnets: []string{"192.168.0.0/25", "192.168.0.128/26", "192.168.0.192/28", "192.168.0.208/30"},
+		},
+		{
+			description:     "host counts exactly filling a /24",
+			cidr:            "192.168.0.0/24",
+			hostCounts:      []int{126, 62, 62},
+			expectedSubnets: []string{"192.168.0.0/25", "192.168.0.128/26", "192.168.0.192/26"},
+		},
+		{
+			description: "host counts exceed the parent",
+			cidr:        "192.168.0.0/24",
+			hostCounts:  []int{100, 100, 100},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := AllocateVLSM(tt.cidr, tt.hostCounts)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+				if i > 0 {
+					_, previousLast := subnets[i-1].addressRange()
+					first, _ := subnet.addressRange()
+					g.Expect(first).To(BeIdenticalTo(previousLast + 1))
+				}
+			}
+			overlaps, err := FindAllOverlaps(tt.expectedSubnets)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(overlaps).To(BeEmpty())
+		})
+	}
+}
+
+func TestSubnetJunosRouteFilter(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("10.0.0.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.JunosRouteFilter()).To(Equal("route-filter 10.0.0.0/24 exact;"))
+	g.Expect(subnet.JunosRouteFilter("orlonger")).To(Equal("route-filter 10.0.0.0/24 orlonger;"))
+	g.Expect(subnet.JunosRouteFilter("longer")).To(Equal("route-filter 10.0.0.0/24 longer;"))
+}
+
+func TestSubnetWildcardMask(t *testing.T) {
+	tests := []struct {
+		description      string
+		cidr             string
+		expectedWildcard string
+	}{
+		{
+			description:      "/26 subnet",
+			cidr:             "10.0.0.0/26",
+			expectedWildcard: "0.0.0.63",
+		},
+		{
+			description:      "/24 subnet",
+			cidr:             "10.0.0.0/24",
+			expectedWildcard: "0.0.0.255",
+		},
+		{
+			description:      "/32 subnet",
+			cidr:             "10.0.0.1/32",
+			expectedWildcard: "0.0.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(net.IP(subnet.WildcardMask).String()).To(Equal(tt.expectedWildcard))
+			g.Expect(subnet.String()).To(ContainSubstring("Wildcard:    " + tt.expectedWildcard + "\n"))
+		})
+	}
+}
+
+func TestLargestFreeSubnet(t *testing.T) {
+	tests := []struct {
+		description    string
+		parent         string
+		used           []string
+		expectedSubnet string
+		expectedErr    bool
+	}{
+		{
+			description:    "/23 free in a partially used /22",
+			parent:         "10.0.0.0/22",
+			used:           []string{"10.0.0.0/24", "10.0.1.128/25"},
+			expectedSubnet: "10.0.2.0/23",
+		},
+		{
+			description:    "unaligned free range",
+			parent:         "10.0.0.0/22",
+			used:           []string{"10.0.0.0/25", "10.0.3.0/24"},
+			expectedSubnet: "10.0.1.0/24",
+		},
+		{
+			description:    "used blocks outside the parent",
+			parent:         "10.0.0.0/24",
+			used:           []string{"10.0.1.0/24"},
+			expectedSubnet: "10.0.0.0/24",
+		},
+		{
+			description: "parent fully used",
+			parent:      "10.0.0.0/24",
+			used:        []string{"10.0.0.0/25", "10.0.0.128/25"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := LargestFreeSubnet(tt.parent, tt.used)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnet))
+		})
+	}
+}
+
+func TestSubnetPrefixLen(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("172.16.0.0/20")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.PrefixLen()).To(BeIdenticalTo(20))
+
+	subnets, err := CalculateSubnets(subnet, net.CIDRMask(22, 32), 1024)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnets).To(HaveLen(4))
+	for _, child := range subnets {
+		g.Expect(child.PrefixLen()).To(BeIdenticalTo(22))
+	}
+
+	subnet, err = CalculateSubnet("2001:db8::/48")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.PrefixLen()).To(BeIdenticalTo(48))
+}
+
+func TestCalculateSubnetsBySubnetCount(t *testing.T) {
+	tests := []struct {
+		description         string
+		sourceNetCIDR       string
+		subnetNumber        int
+		expectedSubnetCount int
+		expectedHostCount   int64
+		expectedErr         bool
+	}{
+		{
+			description:         "10.0.0.0/24 --> 3 subnets",
+			sourceNetCIDR:       "10.0.0.0/24",
+			subnetNumber:        3,
+			expectedSubnetCount: 4,
+			expectedHostCount:   64,
+		},
+		{
+			description:         "10.0.0.0/24 --> 4 subnets",
+			sourceNetCIDR:       "10.0.0.0/24",
+			subnetNumber:        4,
+			expectedSubnetCount: 4,
+			expectedHostCount:   64,
+		},
+		{
+			description:         "10.0.0.0/24 --> 5 subnets",
+			sourceNetCIDR:       "10.0.0.0/24",
+			subnetNumber:        5,
+			expectedSubnetCount: 8,
+			expectedHostCount:   32,
+		},
+		{
+			description:         "10.0.0.0/24 --> 1 subnet",
+			sourceNetCIDR:       "10.0.0.0/24",
+			subnetNumber:        1,
+			expectedSubnetCount: 1,
+			expectedHostCount:   256,
+		},
+		{
+			description:         "0.0.0.0/0 --> 1 subnet",
+			sourceNetCIDR:       "0.0.0.0/0",
+			subnetNumber:        1,
+			expectedSubnetCount: 1,
+			expectedHostCount:   4294967296,
+		},
+		{
+			description:         "0.0.0.0/0 --> 2 subnets",
+			sourceNetCIDR:       "0.0.0.0/0",
+			subnetNumber:        2,
+			expectedSubnetCount: 2,
+			expectedHostCount:   2147483648,
+		},
+		{
+			description:   "10.0.0.0/30 --> 5 subnets",
+			sourceNetCIDR: "10.0.0.0/30",
+			subnetNumber:  5,
+			expectedErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := CalculateSubnetsBySubnetCount(tt.sourceNetCIDR, tt.subnetNumber)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(len(subnets)).To(BeIdenticalTo(tt.expectedSubnetCount))
+			for _, subnet := range subnets {
+				g.Expect(tt.expectedHostCount).To(BeIdenticalTo(int64(subnet.TotalHostsNum)))
+			}
+		})
+	}
+}
+
+func TestStraddlesParents(t *testing.T) {
+	tests := []struct {
+		description       string
+		subnet            string
+		parentPrefix      int
+		expectedStraddles bool
+		expectedErr       bool
+	}{
+		{
+			description:       "/23 at parent prefix /24",
+			subnet:            "10.0.0.0/23",
+			parentPrefix:      24,
+			expectedStraddles: true,
+		},
+		{
+			description:       "/24 at parent prefix /24",
+			subnet:            "10.0.1.0/24",
+			parentPrefix:      24,
+			expectedStraddles: false,
+		},
+		{
+			description:       "/25 at parent prefix /16",
+			subnet:            "10.0.1.128/25",
+			parentPrefix:      16,
+			expectedStraddles: false,
+		},
+		{
+			description:  "invalid parent prefix",
+			subnet:       "10.0.0.0/23",
+			parentPrefix: 33,
+			expectedErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			straddles, err := StraddlesParents(tt.subnet, tt.parentPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(straddles).To(Equal(tt.expectedStraddles))
+		})
+	}
+}
+
+func TestAllocateByPercent(t *testing.T) {
+	tests := []struct {
+		description     string
+		parent          string
+		percents        []int
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "50/25/25 on a /22",
+			parent:          "10.0.0.0/22",
+			percents:        []int{50, 25, 25},
+			expectedSubnets: []string{"10.0.0.0/23", "10.0.2.0/24", "10.0.3.0/24"},
+		},
+		{
+			description:     "shares rounded to the nearest power of two",
+			parent:          "10.0.0.0/22",
+			percents:        []int{10, 30, 60},
+			expectedSubnets: []string{"10.0.3.0/25", "10.0.2.0/24", "10.0.0.0/23"},
+		},
+		{
+			description: "rounding up exceeds the parent",
+			parent:      "10.0.0.0/22",
+			percents:    []int{40, 40, 20},
+			expectedErr: true,
+		},
+		{
+			description: "percentages not summing up to 100",
+			parent:      "10.0.0.0/22",
+			percents:    []int{50, 25},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := AllocateByPercent(tt.parent, tt.percents)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		description      string
+		cidr             string
+		newPrefix        int
+		expectedChildren []string
+		expectedErr      bool
+	}{
+		{
+			description:      "/16 into /18",
+			cidr:             "172.16.0.0/16",
+			newPrefix:        18,
+			expectedChildren: []string{"172.16.0.0/18", "172.16.64.0/18", "172.16.128.0/18", "172.16.192.0/18"},
+		},
+		{
+			description:      "unaligned address split from its network",
+			cidr:             "10.0.0.77/30",
+			newPrefix:        31,
+			expectedChildren: []string{"10.0.0.76/31", "10.0.0.78/31"},
+		},
+		{
+			description: "same prefix length",
+			cidr:        "172.16.0.0/16",
+			newPrefix:   16,
+			expectedErr: true,
+		},
+		{
+			description: "shorter prefix length",
+			cidr:        "172.16.0.0/16",
+			newPrefix:   8,
+			expectedErr: true,
+		},
+		{
+			description: "prefix length beyond /32",
+			cidr:        "172.16.0.0/16",
+			newPrefix:   33,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			children, err := subnet.Split(tt.newPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(children).To(HaveLen(len(tt.expectedChildren)))
+			for i, child := range children {
+				expected, err := CalculateSubnet(tt.expectedChildren[i])
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(child).To(Equal(expected))
+			}
+		})
+	}
+}
+
+func TestHostsExcluding(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		excluded      string
+		expectedCount int
+		expectedFirst string
+		expectedLast  string
+		expectedErr   bool
+	}{
+		{
+			description:   "/27 excluding an inner /29",
+			cidr:          "10.0.0.0/27",
+			excluded:      "10.0.0.8/29",
+			expectedCount: 22,
+			expectedFirst: "10.0.0.1",
+			expectedLast:  "10.0.0.30",
+		},
+		{
+			description:   "/27 excluding its first /29",
+			cidr:          "10.0.0.0/27",
+			excluded:      "10.0.0.0/29",
+			expectedCount: 23,
+			expectedFirst: "10.0.0.8",
+			expectedLast:  "10.0.0.30",
+		},
+		{
+			description: "excluded subnet not contained",
+			cidr:        "10.0.0.0/27",
+			excluded:    "10.0.0.32/29",
+			expectedErr: true,
+		},
+		{
+			description: "excluded subnet larger than the subnet",
+			cidr:        "10.0.0.0/27",
+			excluded:    "10.0.0.0/26",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			IPs, err := HostsExcluding(tt.cidr, tt.excluded)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(IPs).To(HaveLen(tt.expectedCount))
+			g.Expect(IPs[0].String()).To(Equal(tt.expectedFirst))
+			g.Expect(IPs[len(IPs)-1].String()).To(Equal(tt.expectedLast))
+			_, excludedNet, _ := net.ParseCIDR(tt.excluded)
+			for _, ip := range IPs {
+				g.Expect(excludedNet.Contains(ip)).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestReverseDNSZones(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		expectedZones []string
+	}{
+		{
+			description:   "/24",
+			cidr:          "192.168.1.0/24",
+			expectedZones: []string{"1.168.192.in-addr.arpa"},
+		},
+		{
+			description:   "/26 classless delegation",
+			cidr:          "192.168.1.0/26",
+			expectedZones: []string{"0/26.1.168.192.in-addr.arpa"},
+		},
+		{
+			description:   "/26 not being the first block",
+			cidr:          "192.168.1.128/26",
+			expectedZones: []string{"128/26.1.168.192.in-addr.arpa"},
+		},
+		{
+			description:   "/16",
+			cidr:          "172.16.0.0/16",
+			expectedZones: []string{"16.172.in-addr.arpa"},
+		},
+		{
+			description:   "/8",
+			cidr:          "10.0.0.0/8",
+			expectedZones: []string{"10.in-addr.arpa"},
+		},
+		{
+			description:   "/22 split into /24 zones",
+			cidr:          "10.0.4.0/22",
+			expectedZones: []string{"4.0.10.in-addr.arpa", "5.0.10.in-addr.arpa", "6.0.10.in-addr.arpa", "7.0.10.in-addr.arpa"},
+		},
+		{
+			description:   "/32",
+			cidr:          "10.0.0.1/32",
+			expectedZones: []string{"1.0.0.10.in-addr.arpa"},
+		},
+		{
+			description: "IPv6",
+			cidr:        "2001:db8::/64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.ReverseDNSZones()).To(Equal(tt.expectedZones))
+		})
+	}
+}
+
+func TestNextPrevious(t *testing.T) {
+	tests := []struct {
+		description         string
+		cidr                string
+		expectedNext        string
+		expectedPrevious    string
+		expectedNextErr     bool
+		expectedPreviousErr bool
+	}{
+		{
+			description:      "/24",
+			cidr:             "10.0.0.0/24",
+			expectedNext:     "10.0.1.0/24",
+			expectedPrevious: "9.255.255.0/24",
+		},
+		{
+			description:      "unaligned address",
+			cidr:             "10.0.0.77/26",
+			expectedNext:     "10.0.0.128/26",
+			expectedPrevious: "10.0.0.0/26",
+		},
+		{
+			description:      "last /24",
+			cidr:             "255.255.255.0/24",
+			expectedNextErr:  true,
+			expectedPrevious: "255.255.254.0/24",
+		},
+		{
+			description:         "first /32",
+			cidr:                "0.0.0.0/32",
+			expectedNext:        "0.0.0.1/32",
+			expectedPreviousErr: true,
+		},
+		{
+			description:         "whole address space",
+			cidr:                "0.0.0.0/0",
+			expectedNextErr:     true,
+			expectedPreviousErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			next, err := subnet.Next()
+			if tt.expectedNextErr {
+				g.Expect(err).Should(HaveOccurred())
+			} else {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(next.NetworkCIDR).To(Equal(tt.expectedNext))
+			}
+
+			previous, err := subnet.Previous()
+			if tt.expectedPreviousErr {
+				g.Expect(err).Should(HaveOccurred())
+			} else {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(previous.NetworkCIDR).To(Equal(tt.expectedPrevious))
+			}
+		})
+	}
+}
+
+func TestCalculateSubnetIPv4Form(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("10.0.0.77/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	for _, ip := range []net.IP{subnet.IP, subnet.Network.IP, subnet.BroadcastIP, subnet.HostMinIP, subnet.HostMaxIP} {
+		g.Expect(ip).To(HaveLen(net.IPv4len))
+	}
+}
+
+func FuzzIPToIntRoundTrip(f *testing.F) {
+	for _, seed := range []net.IP{
+		net.IPv4(0, 0, 0, 0).To4(),
+		net.IPv4(10, 0, 0, 1).To4(),
+		net.IPv4(255, 255, 255, 255).To4(),
+		net.IPv4(192, 168, 1, 10),
+		net.IPv4(255, 255, 255, 255),
+		net.ParseIP("2001:db8::1"),
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, IPBytes []byte) {
+		ip := net.IP(IPBytes)
+		if ip.To4() == nil {
+			if _, err := ipv4ToInt(ip); err == nil {
+				t.Errorf("ipv4ToInt(%v) succeeded for an address not being IPv4", IPBytes)
+			}
+			return
+		}
+		intIP := ipToInt(ip)
+		checkedIntIP, err := ipv4ToInt(ip)
+		if err != nil || checkedIntIP != intIP {
+			t.Errorf("ipv4ToInt(%s) = %d, %v, expected %d", ip, checkedIntIP, err, intIP)
+		}
+		if roundTrip := intToIP(intIP); !bytes.Equal(roundTrip, ip.To4()) {
+			t.Errorf("intToIP(ipToInt(%s)) = %v, expected %v", ip, []byte(roundTrip), []byte(ip.To4()))
+		}
+	})
+}
+
+func TestGetHostIPsForSubnetReversed(t *testing.T) {
+	g := NewWithT(t)
+
+	IPs, err := GetHostIPsForSubnetReversed("10.0.0.16/28")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(IPs).To(HaveLen(14))
+	g.Expect(IPs[0].String()).To(Equal("10.0.0.30"))
+	g.Expect(IPs[1].String()).To(Equal("10.0.0.29"))
+	g.Expect(IPs[len(IPs)-1].String()).To(Equal("10.0.0.17"))
+
+	_, err = GetHostIPsForSubnetReversed("10.0.0.16/33")
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestCalculateSubnetFromMask(t *testing.T) {
+	tests := []struct {
+		description  string
+		ip           string
+		mask         string
+		expectedCIDR string
+		expectedErr  bool
+	}{
+		{
+			description:  "/24 netmask",
+			ip:           "192.168.1.0",
+			mask:         "255.255.255.0",
+			expectedCIDR: "192.168.1.0/24",
+		},
+		{
+			description:  "/26 netmask with a host address",
+			ip:           "192.168.1.77",
+			mask:         "255.255.255.192",
+			expectedCIDR: "192.168.1.64/26",
+		},
+		{
+			description:  "/0 netmask",
+			ip:           "192.168.1.0",
+			mask:         "0.0.0.0",
+			expectedCIDR: "0.0.0.0/0",
+		},
+		{
+			description: "non-contiguous netmask",
+			ip:          "192.168.1.0",
+			mask:        "255.0.255.0",
+			expectedErr: true,
+		},
+		{
+			description: "invalid netmask",
+			ip:          "192.168.1.0",
+			mask:        "255.255.255",
+			expectedErr: true,
+		},
+		{
+			description: "invalid address",
+			ip:          "192.168.1",
+			mask:        "255.255.255.0",
+			expectedErr: true,
+		},
+		{
+			description: "IPv6 address",
+			ip:          "2001:db8::",
+			mask:        "255.255.255.0",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnetFromMask(tt.ip, tt.mask)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.Network.String()).To(Equal(tt.expectedCIDR))
+		})
+	}
+}
+
+func TestWriteSubnetsCSV(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, err := CalculateSubnetsByCIDR("10.0.0.0/24", 26, 3)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	var buf bytes.Buffer
+	g.Expect(WriteSubnetsCSV(&buf, subnets)).To(Succeed())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(records).To(HaveLen(4))
+	g.Expect(records[0]).To(Equal([]string{"network_cidr", "host_min", "host_max", "broadcast", "hosts", "total_hosts"}))
+	g.Expect(records[1]).To(Equal([]string{"10.0.0.0/26", "10.0.0.1", "10.0.0.62", "10.0.0.63", "62", "64"}))
+}
+
+func TestAllocationTable(t *testing.T) {
+	tests := []struct {
+		description     string
+		parent          string
+		childPrefix     int
+		expectedSubnets []string
+		expectedStarts  []int
+		expectedEnds    []int
+		expectedErr     bool
+	}{
+		{
+			description:     "/22 into /24",
+			parent:          "10.0.0.0/22",
+			childPrefix:     24,
+			expectedSubnets: []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
+			expectedStarts:  []int{0, 256, 512, 768},
+			expectedEnds:    []int{255, 511, 767, 1023},
+		},
+		{
+			description:     "/30 into /32",
+			parent:          "10.0.0.4/30",
+			childPrefix:     32,
+			expectedSubnets: []string{"10.0.0.4/32", "10.0.0.5/32", "10.0.0.6/32", "10.0.0.7/32"},
+			expectedStarts:  []int{0, 1, 2, 3},
+			expectedEnds:    []int{0, 1, 2, 3},
+		},
+		{
+			description: "child prefix shorter than the parent prefix",
+			parent:      "10.0.0.0/22",
+			childPrefix: 21,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			table, err := AllocationTable(tt.parent, tt.childPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(table).To(HaveLen(len(tt.expectedSubnets)))
+			for i, entry := range table {
+				g.Expect(entry.Subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+				g.Expect(entry.StartOffset).To(Equal(tt.expectedStarts[i]))
+				g.Expect(entry.EndOffset).To(Equal(tt.expectedEnds[i]))
+			}
+		})
+	}
+}
+
+func TestIsMergeReady(t *testing.T) {
+	tests := []struct {
+		description        string
+		cidr               string
+		targetPrefix       int
+		expectedMergeReady bool
+		expectedErr        bool
+	}{
+		{
+			description:        "lower half of a /24",
+			cidr:               "10.0.0.0/25",
+			targetPrefix:       24,
+			expectedMergeReady: true,
+		},
+		{
+			description:        "upper half of a /24",
+			cidr:               "10.0.0.128/25",
+			targetPrefix:       24,
+			expectedMergeReady: false,
+		},
+		{
+			description:        "lower sibling of a /23 but not of the /22",
+			cidr:               "10.0.2.0/24",
+			targetPrefix:       22,
+			expectedMergeReady: false,
+		},
+		{
+			description:        "first /24 of a /22",
+			cidr:               "10.0.4.0/24",
+			targetPrefix:       22,
+			expectedMergeReady: true,
+		},
+		{
+			description:  "target prefix not shorter than the subnet prefix",
+			cidr:         "10.0.0.0/25",
+			targetPrefix: 25,
+			expectedErr:  true,
+		},
+		{
+			description:  "IPv6 subnet",
+			cidr:         "2001:db8::/65",
+			targetPrefix: 64,
+			expectedErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			mergeReady, err := subnet.IsMergeReady(tt.targetPrefix)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(mergeReady).To(Equal(tt.expectedMergeReady))
+		})
+	}
+}
+
+func TestSubnetClass(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		expectedClass string
+	}{
+		{
+			description:   "class A",
+			cidr:          "10.0.0.0/8",
+			expectedClass: "A",
+		},
+		{
+			description:   "class A upper boundary",
+			cidr:          "127.255.255.255/32",
+			expectedClass: "A",
+		},
+		{
+			description:   "class B",
+			cidr:          "172.16.0.0/12",
+			expectedClass: "B",
+		},
+		{
+			description:   "class C",
+			cidr:          "192.168.1.0/24",
+			expectedClass: "C",
+		},
+		{
+			description:   "class D",
+			cidr:          "224.0.0.1/32",
+			expectedClass: "D",
+		},
+		{
+			description:   "class E",
+			cidr:          "240.0.0.0/4",
+			expectedClass: "E",
+		},
+		{
+			description:   "limited broadcast",
+			cidr:          "255.255.255.255/32",
+			expectedClass: "E",
+		},
+		{
+			description:   "IPv6",
+			cidr:          "2001:db8::/64",
+			expectedClass: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.Class()).To(Equal(tt.expectedClass))
+		})
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	tests := []struct {
+		description     string
+		ip              string
+		expectedPrivate bool
+	}{
+		{
+			description:     "10/8",
+			ip:              "10.1.2.3",
+			expectedPrivate: true,
+		},
+		{
+			description:     "172.16/12 upper boundary",
+			ip:              "172.31.255.255",
+			expectedPrivate: true,
+		},
+		{
+			description:     "beyond 172.16/12",
+			ip:              "172.32.0.0",
+			expectedPrivate: false,
+		},
+		{
+			description:     "192.168/16",
+			ip:              "192.168.1.10",
+			expectedPrivate: true,
+		},
+		{
+			description:     "public address",
+			ip:              "8.8.8.8",
+			expectedPrivate: false,
+		},
+		{
+			description:     "IPv6 address",
+			ip:              "fd00::1",
+			expectedPrivate: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(IsPrivate(net.ParseIP(tt.ip))).To(Equal(tt.expectedPrivate))
+		})
+	}
+}
+
+func TestPlanJSON(t *testing.T) {
+	g := NewWithT(t)
+
+	data, err := PlanJSON("10.0.0.0/22", 24)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	var children []subnetJSON
+	g.Expect(json.Unmarshal(data, &children)).To(Succeed())
+	g.Expect(children).To(HaveLen(4))
+	g.Expect(children[0].NetworkCIDR).To(Equal("10.0.0.0/24"))
+	g.Expect(children[3].Broadcast).To(Equal("10.0.3.255"))
+
+	_, err = PlanJSON("10.0.0.0/22", 21)
+	g.Expect(err).Should(HaveOccurred())
+	_, err = PlanJSON("10.0.0.0/33", 24)
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestCalculateSubnetShortPrefixes(t *testing.T) {
+	tests := []struct {
+		description           string
+		cidr                  string
+		expectedHostsNum      int64
+		expectedTotalHostsNum int64
+	}{
+		{
+			description:           "/0",
+			cidr:                  "0.0.0.0/0",
+			expectedHostsNum:      4294967294,
+			expectedTotalHostsNum: 4294967296,
+		},
+		{
+			description:           "/1",
+			cidr:                  "128.0.0.0/1",
+			expectedHostsNum:      2147483646,
+			expectedTotalHostsNum: 2147483648,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(int64(subnet.HostsNum)).To(Equal(tt.expectedHostsNum))
+			g.Expect(int64(subnet.TotalHostsNum)).To(Equal(tt.expectedTotalHostsNum))
+		})
+	}
+}
+
+func TestSubnetDetailedString(t *testing.T) {
+	tests := []struct {
+		description    string
+		cidr           string
+		expectedString string
+	}{
+		{
+			description: "private /24 host address",
+			cidr:        "192.168.1.10/24",
+			expectedString: "" +
+				"Address:   192.168.1.10         11000000.10101000.00000001. 00001010\n" +
+				"Netmask:   255.255.255.0 = 24   11111111.11111111.11111111. 00000000\n" +
+				"Wildcard:  0.0.0.255            00000000.00000000.00000000. 11111111\n" +
+				"=>\n" +
+				"Network:   192.168.1.0/24       11000000.10101000.00000001. 00000000\n" +
+				"HostMin:   192.168.1.1          11000000.10101000.00000001. 00000001\n" +
+				"HostMax:   192.168.1.254        11000000.10101000.00000001. 11111110\n" +
+				"Broadcast: 192.168.1.255        11000000.10101000.00000001. 11111111\n" +
+				"Hosts/Net: 254                  Class C, Private Internet\n",
+		},
+		{
+			description: "public /26",
+			cidr:        "8.8.8.64/26",
+			expectedString: "" +
+				"Address:   8.8.8.64             00001000.00001000.00001000.01 000000\n" +
+				"Netmask:   255.255.255.192 = 26 11111111.11111111.11111111.11 000000\n" +
+				"Wildcard:  0.0.0.63             00000000.00000000.00000000.00 111111\n" +
+				"=>\n" +
+				"Network:   8.8.8.64/26          00001000.00001000.00001000.01 000000\n" +
+				"HostMin:   8.8.8.65             00001000.00001000.00001000.01 000001\n" +
+				"HostMax:   8.8.8.126            00001000.00001000.00001000.01 111110\n" +
+				"Broadcast: 8.8.8.127            00001000.00001000.00001000.01 111111\n" +
+				"Hosts/Net: 62                   Class A\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.DetailedString()).To(Equal(tt.expectedString))
+		})
+	}
+}
+
+func TestSubnetRemainingHosts(t *testing.T) {
+	tests := []struct {
+		description       string
+		cidr              string
+		assigned          []string
+		expectedRemaining int
+		expectedErr       bool
+	}{
+		{
+			description:       "/29 with three assigned addresses",
+			cidr:              "10.0.0.0/29",
+			assigned:          []string{"10.0.0.1", "10.0.0.2", "10.0.0.6"},
+			expectedRemaining: 3,
+		},
+		{
+			description:       "duplicates and addresses outside of the host range",
+			cidr:              "10.0.0.0/29",
+			assigned:          []string{"10.0.0.1", "10.0.0.1", "10.0.0.0", "10.0.0.7", "10.0.0.9"},
+			expectedRemaining: 5,
+		},
+		{
+			description:       "nothing assigned",
+			cidr:              "10.0.0.3/29",
+			expectedRemaining: 6,
+		},
+		{
+			description: "invalid address",
+			cidr:        "10.0.0.0/29",
+			assigned:    []string{"10.0.0"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			remaining, err := subnet.RemainingHosts(tt.assigned)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(remaining).To(Equal(tt.expectedRemaining))
+		})
+	}
+}
+
+func TestSubnetCompare(t *testing.T) {
+	tests := []struct {
+		description     string
+		a               string
+		b               string
+		expectedCompare int
+	}{
+		{
+			description:     "lower network address",
+			a:               "10.0.0.0/24",
+			b:               "10.0.1.0/24",
+			expectedCompare: -1,
+		},
+		{
+			description:     "shorter prefix at the same network address",
+			a:               "10.0.0.0/24",
+			b:               "10.0.0.0/25",
+			expectedCompare: -1,
+		},
+		{
+			description:     "higher network address with a shorter prefix",
+			a:               "10.0.1.0/24",
+			b:               "10.0.0.0/25",
+			expectedCompare: 1,
+		},
+		{
+			description:     "same network from different addresses",
+			a:               "10.0.0.77/24",
+			b:               "10.0.0.0/24",
+			expectedCompare: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			a, err := CalculateSubnet(tt.a)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			b, err := CalculateSubnet(tt.b)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(a.Compare(b)).To(Equal(tt.expectedCompare))
+			g.Expect(b.Compare(a)).To(Equal(-tt.expectedCompare))
+			g.Expect(a.Equal(b)).To(Equal(tt.expectedCompare == 0))
+		})
+	}
+}
+
+func TestApproximateCover(t *testing.T) {
+	tests := []struct {
+		description     string
+		cidrs           []string
+		maxRoutes       int
+		expectedSubnets []string
+		expectedExtra   int64
+		expectedErr     bool
+	}{
+		{
+			description:     "exact aggregation within the route limit",
+			cidrs:           []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.4.0/24"},
+			maxRoutes:       2,
+			expectedSubnets: []string{"10.0.0.0/23", "10.0.4.0/24"},
+		},
+		{
+			description:     "more blocks than routes",
+			cidrs:           []string{"10.0.0.0/24", "10.0.2.0/24", "10.0.8.0/24", "10.0.9.0/24", "10.0.64.0/24"},
+			maxRoutes:       3,
+			expectedSubnets: []string{"10.0.0.0/22", "10.0.8.0/23", "10.0.64.0/24"},
+			expectedExtra:   2 * 256,
+		},
+		{
+			description:     "single route",
+			cidrs:           []string{"10.0.0.0/24", "10.0.3.0/24"},
+			maxRoutes:       1,
+			expectedSubnets: []string{"10.0.0.0/22"},
+			expectedExtra:   2 * 256,
+		},
+		{
+			description: "invalid maximal route count",
+			cidrs:       []string{"10.0.0.0/24"},
+			maxRoutes:   0,
+			expectedErr: true,
+		},
+		{
+			description: "invalid CIDR block",
+			cidrs:       []string{"10.0.0.0/33"},
+			maxRoutes:   1,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, extra, err := ApproximateCover(tt.cidrs, tt.maxRoutes)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range subnets {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+			g.Expect(extra).To(Equal(tt.expectedExtra))
+		})
+	}
+}
+
+func TestIPToBinaryString(t *testing.T) {
+	tests := []struct {
+		description    string
+		ip             net.IP
+		expectedBinary string
+	}{
+		{
+			description:    "all zeros",
+			ip:             net.ParseIP("0.0.0.0"),
+			expectedBinary: "00000000.00000000.00000000.00000000",
+		},
+		{
+			description:    "all ones",
+			ip:             net.ParseIP("255.255.255.255"),
+			expectedBinary: "11111111.11111111.11111111.11111111",
+		},
+		{
+			description:    "host address",
+			ip:             net.ParseIP("192.168.1.10"),
+			expectedBinary: "11000000.10101000.00000001.00001010",
+		},
+		{
+			description:    "4-byte form",
+			ip:             net.IPv4(192, 168, 1, 10).To4(),
+			expectedBinary: "11000000.10101000.00000001.00001010",
+		},
+		{
+			description:    "IPv6 address",
+			ip:             net.ParseIP("2001:db8::1"),
+			expectedBinary: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(IPToBinaryString(tt.ip)).To(Equal(tt.expectedBinary))
+		})
+	}
+}
+
+func TestSubnetNetworkMaskBinary(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("192.168.1.10/26")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.NetworkMaskBinary()).To(Equal("11111111.11111111.11111111.11000000"))
+
+	subnet, err = CalculateSubnet("2001:db8::/64")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.NetworkMaskBinary()).To(BeEmpty())
+}
+
+func TestSubnetBroadcast(t *testing.T) {
+	tests := []struct {
+		description         string
+		cidr                string
+		expectedBroadcast   net.IP
+		expectedBroadcastIP string
+	}{
+		{
+			description:         "/24",
+			cidr:                "10.0.0.77/24",
+			expectedBroadcast:   net.IPv4(10, 0, 0, 255).To4(),
+			expectedBroadcastIP: "10.0.0.255",
+		},
+		{
+			description:         "/30",
+			cidr:                "10.0.0.4/30",
+			expectedBroadcast:   net.IPv4(10, 0, 0, 7).To4(),
+			expectedBroadcastIP: "10.0.0.7",
+		},
+		{
+			description:         "/31 without broadcast, legacy higher address",
+			cidr:                "10.0.0.4/31",
+			expectedBroadcast:   nil,
+			expectedBroadcastIP: "10.0.0.5",
+		},
+		{
+			description:         "/32 single address",
+			cidr:                "10.0.0.5/32",
+			expectedBroadcast:   net.IPv4(10, 0, 0, 5).To4(),
+			expectedBroadcastIP: "10.0.0.5",
+		},
+		{
+			description:         "IPv6 without broadcast",
+			cidr:                "2001:db8::/126",
+			expectedBroadcast:   nil,
+			expectedBroadcastIP: "2001:db8::3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.Broadcast()).To(Equal(tt.expectedBroadcast))
+			g.Expect(subnet.BroadcastIP.String()).To(Equal(tt.expectedBroadcastIP))
+		})
+	}
+}
+
+func TestCalculateSubnetsByCIDRValidation(t *testing.T) {
+	tests := []struct {
+		description         string
+		sourceNetCIDR       string
+		subnetCIDR          uint32
+		expectedSubnetCount int
+		expectedErr         string
+	}{
+		{
+			description:         "target prefix longer than source prefix",
+			sourceNetCIDR:       "10.0.0.0/24",
+			subnetCIDR:          26,
+			expectedSubnetCount: 4,
+		},
+		{
+			description:   "target prefix shorter than source prefix",
+			sourceNetCIDR: "10.0.0.0/24",
+			subnetCIDR:    16,
+			expectedErr:   "target prefix /16 must be longer than source prefix /24",
+		},
+		{
+			description:   "target prefix equal to source prefix",
+			sourceNetCIDR: "10.0.0.0/24",
+			subnetCIDR:    24,
+			expectedErr:   "target prefix /24 must be longer than source prefix /24",
+		},
+		{
+			description:   "target prefix beyond /32",
+			sourceNetCIDR: "10.0.0.0/24",
+			subnetCIDR:    33,
+			expectedErr:   "invalid prefix length 33",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets, err := CalculateSubnetsByCIDR(tt.sourceNetCIDR, tt.subnetCIDR)
+			if tt.expectedErr != "" {
+				g.Expect(err).To(MatchError(tt.expectedErr))
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnets).To(HaveLen(tt.expectedSubnetCount))
+		})
+	}
+}
+
+func TestAdjacencyEdges(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidrs         []string
+		expectedEdges [][2]int
+		expectedErr   bool
+	}{
+		{
+			description:   "contiguous run of /24",
+			cidrs:         []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
+			expectedEdges: [][2]int{{0, 1}, {1, 2}, {2, 3}},
+		},
+		{
+			description:   "unsorted subnets of different sizes with a gap",
+			cidrs:         []string{"10.0.2.0/23", "10.0.0.0/24", "10.0.8.0/24", "10.0.1.0/24"},
+			expectedEdges: [][2]int{{0, 3}, {1, 3}},
+		},
+		{
+			description:   "last subnet of the address space",
+			cidrs:         []string{"255.255.255.0/24", "0.0.0.0/24"},
+			expectedEdges: [][2]int{},
+		},
+		{
+			description: "IPv6 subnet",
+			cidrs:       []string{"10.0.0.0/24", "2001:db8::/64"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnets := make([]*Subnet, len(tt.cidrs))
+			for i, cidr := range tt.cidrs {
+				subnet, err := CalculateSubnet(cidr)
+				g.Expect(err).ShouldNot(HaveOccurred())
+				subnets[i] = subnet
+			}
+			edges, err := AdjacencyEdges(subnets)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(edges).To(Equal(tt.expectedEdges))
+		})
+	}
+}
+
+func TestSubnetHostRange(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		expectedFirst string
+		expectedLast  string
+	}{
+		{
+			description:   "/24",
+			cidr:          "192.168.1.0/24",
+			expectedFirst: "192.168.1.1",
+			expectedLast:  "192.168.1.254",
+		},
+		{
+			description:   "/24 from a host address",
+			cidr:          "192.168.1.10/24",
+			expectedFirst: "192.168.1.1",
+			expectedLast:  "192.168.1.254",
+		},
+		{
+			description:   "/31",
+			cidr:          "10.0.0.4/31",
+			expectedFirst: "10.0.0.4",
+			expectedLast:  "10.0.0.5",
+		},
+		{
+			description:   "/32",
+			cidr:          "10.0.0.5/32",
+			expectedFirst: "10.0.0.5",
+			expectedLast:  "10.0.0.5",
+		},
+		{
+			description:   "IPv6",
+			cidr:          "2001:db8::/126",
+			expectedFirst: "2001:db8::",
+			expectedLast:  "2001:db8::3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			first, last := subnet.HostRange()
+			g.Expect(first.String()).To(Equal(tt.expectedFirst))
+			g.Expect(last.String()).To(Equal(tt.expectedLast))
+		})
+	}
+}
+
+func TestRightSize(t *testing.T) {
+	tests := []struct {
+		description  string
+		subnet       string
+		peakHosts    int
+		expectedCIDR string
+		expectedErr  bool
+	}{
+		{
+			description:  "/24 with 50 hosts",
+			subnet:       "10.0.0.0/24",
+			peakHosts:    50,
+			expectedCIDR: "10.0.0.0/26",
+		},
+		{
+			description:  "/24 with 62 hosts filling a /26",
+			subnet:       "10.0.1.0/24",
+			peakHosts:    62,
+			expectedCIDR: "10.0.1.0/26",
+		},
+		{
+			description:  "/24 with 63 hosts",
+			subnet:       "10.0.1.0/24",
+			peakHosts:    63,
+			expectedCIDR: "10.0.1.0/25",
+		},
+		{
+			description:  "fully used /24",
+			subnet:       "10.0.1.77/24",
+			peakHosts:    254,
+			expectedCIDR: "10.0.1.0/24",
+		},
+		{
+			description: "more hosts than the subnet provides",
+			subnet:      "10.0.1.0/24",
+			peakHosts:   255,
+			expectedErr: true,
+		},
+		{
+			description: "invalid host count",
+			subnet:      "10.0.1.0/24",
+			peakHosts:   0,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rightSized, err := RightSize(tt.subnet, tt.peakHosts)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(rightSized.NetworkCIDR).To(Equal(tt.expectedCIDR))
+		})
+	}
+}
+
+func TestExplainSubnet(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		expectedSteps []string
+		expectedErr   bool
+	}{
+		{
+			description: "/26",
+			cidr:        "10.0.0.0/26",
+			expectedSteps: []string{
+				"Prefix /26 sets the first 26 of 32 bits of the netmask: 255.255.255.192",
+				"Applying the netmask 255.255.255.192 to 10.0.0.0 results in the network address 10.0.0.0",
+				"The remaining 6 host bits address 64 addresses",
+				"Setting all host bits of the network address results in the broadcast address 10.0.0.63",
+				"Stripping the network and the broadcast address leaves 62 usable hosts from 10.0.0.1 to 10.0.0.62",
+			},
+		},
+		{
+			description: "/31 from a host address",
+			cidr:        "10.0.0.5/31",
+			expectedSteps: []string{
+				"Prefix /31 sets the first 31 of 32 bits of the netmask: 255.255.255.254",
+				"Applying the netmask 255.255.255.254 to 10.0.0.5 results in the network address 10.0.0.4",
+				"The remaining 1 host bits address 2 addresses",
+				"Setting all host bits of the network address results in the broadcast address 10.0.0.5",
+				"A /31 reserves no network and broadcast address, leaving 2 usable hosts from 10.0.0.4 to 10.0.0.5",
+			},
+		},
+		{
+			description: "IPv6",
+			cidr:        "2001:db8::/64",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			steps, err := ExplainSubnet(tt.cidr)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(steps).To(Equal(tt.expectedSteps))
+		})
+	}
+}
+
+func TestSubnetAddressCount(t *testing.T) {
+	tests := []struct {
+		description   string
+		cidr          string
+		expectedCount uint64
+	}{
+		{
+			description:   "/0",
+			cidr:          "0.0.0.0/0",
+			expectedCount: 4294967296,
+		},
+		{
+			description:   "/24",
+			cidr:          "10.0.0.77/24",
+			expectedCount: 256,
+		},
+		{
+			description:   "/32",
+			cidr:          "10.0.0.1/32",
+			expectedCount: 1,
+		},
+		{
+			description:   "IPv6 /65",
+			cidr:          "2001:db8::/65",
+			expectedCount: 1 << 63,
+		},
+		{
+			description:   "IPv6 /32 capped",
+			cidr:          "2001:db8::/32",
+			expectedCount: math.MaxUint64,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.AddressCount()).To(Equal(tt.expectedCount))
+		})
+	}
+}
+
+func TestIntersectLists(t *testing.T) {
+	tests := []struct {
+		description     string
+		a               []string
+		b               []string
+		expectedSubnets []string
+		expectedErr     bool
+	}{
+		{
+			description:     "/24 within a /23",
+			a:               []string{"10.0.0.0/23"},
+			b:               []string{"10.0.1.0/24"},
+			expectedSubnets: []string{"10.0.1.0/24"},
+		},
+		{
+			description:     "partially overlapping lists",
+			a:               []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.8.0/22"},
+			b:               []string{"10.0.1.128/25", "10.0.0.0/22", "10.0.10.0/23"},
+			expectedSubnets: []string{"10.0.0.0/23", "10.0.10.0/23"},
+		},
+		{
+			description:     "disjoint lists",
+			a:               []string{"10.0.0.0/24"},
+			b:               []string{"10.0.1.0/24"},
+			expectedSubnets: []string{},
+		},
+		{
+			description: "invalid CIDR block",
+			a:           []string{"10.0.0.0/24"},
+			b:           []string{"10.0.1.0/33"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			intersection, err := IntersectLists(tt.a, tt.b)
+			if tt.expectedErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(intersection).To(HaveLen(len(tt.expectedSubnets)))
+			for i, subnet := range intersection {
+				g.Expect(subnet.NetworkCIDR).To(Equal(tt.expectedSubnets[i]))
+			}
+		})
+	}
+}
+
+func TestCalculateSubnetsRequestedCountError(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := CalculateSubnetsByCIDR("10.0.0.0/24", 26, 50)
+	g.Expect(err).To(MatchError("requested subnet count 50 exceeds maximal possible subnet count 4"))
+}
+
+func TestSubnetString(t *testing.T) {
+	g := NewWithT(t)
+
+	subnet, err := CalculateSubnet("192.168.1.0/24")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnet.MaskString()).To(Equal("255.255.255.0"))
+	g.Expect(subnet.NetworkMask.String()).To(Equal("ffffff00"))
+	g.Expect(subnet.String()).To(HavePrefix("192.168.1.0/255.255.255.0\n"))
+	g.Expect(subnet.String()).ToNot(ContainSubstring("ffffff00"))
+}
+
+func TestCalculateSubnetHostFieldsMatchHostRange(t *testing.T) {
+	tests := []struct {
+		description           string
+		cidr                  string
+		expectedHostMinIP     string
+		expectedHostMaxIP     string
+		expectedHostsNum      int
+		expectedTotalHostsNum int
+	}{
+		{
+			description:           "host address of a /25",
+			cidr:                  "10.0.0.130/25",
+			expectedHostMinIP:     "10.0.0.129",
+			expectedHostMaxIP:     "10.0.0.254",
+			expectedHostsNum:      126,
+			expectedTotalHostsNum: 128,
+		},
+		{
+			description:           "host address of a /31",
+			cidr:                  "10.0.0.5/31",
+			expectedHostMinIP:     "10.0.0.4",
+			expectedHostMaxIP:     "10.0.0.5",
+			expectedHostsNum:      2,
+			expectedTotalHostsNum: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.IP.String()).To(Equal(strings.Split(tt.cidr, "/")[0]))
+			hostMin, hostMax := subnet.HostRange()
+			g.Expect(subnet.HostMinIP).To(Equal(hostMin))
+			g.Expect(subnet.HostMaxIP).To(Equal(hostMax))
+			g.Expect(subnet.HostMinIP.String()).To(Equal(tt.expectedHostMinIP))
+			g.Expect(subnet.HostMaxIP.String()).To(Equal(tt.expectedHostMaxIP))
+			g.Expect(subnet.HostsNum).To(Equal(tt.expectedHostsNum))
+			g.Expect(subnet.TotalHostsNum).To(Equal(tt.expectedTotalHostsNum))
+
+			IPs, err := GetHostIPsForSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(IPs).To(HaveLen(tt.expectedHostsNum))
+			g.Expect(IPs[0].String()).To(Equal(tt.expectedHostMinIP))
+		})
+	}
+}
+
+func TestCalculateSubnetsBySubnetCountWholeAddressSpace(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, err := CalculateSubnetsBySubnetCount("0.0.0.0/0", 1, 1)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnets).To(HaveLen(1))
+	g.Expect(subnets[0].Network.String()).To(Equal("0.0.0.0/0"))
+
+	_, err = CalculateSubnetsBySubnetCount("0.0.0.0/0", 1, 2)
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestCalculateSubnetsByHostCountWholeAddressSpace(t *testing.T) {
+	g := NewWithT(t)
+
+	subnets, err := CalculateSubnetsByHostCount("0.0.0.0/0", 0x80000000)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnets).To(HaveLen(1))
+	g.Expect(subnets[0].Network.String()).To(Equal("0.0.0.0/0"))
+
+	_, err = CalculateSubnetsByHostCount("0.0.0.0/0", 0xFFFFFFFF, 2)
+	g.Expect(err).Should(HaveOccurred())
+
+	subnets, err = CalculateSubnetsByHostCount("10.0.0.0/8", 0x80000000)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(subnets).To(BeEmpty())
+
+	sourceNet, err := CalculateSubnet("0.0.0.0/0")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	_, err = CalculateSubnets(sourceNet, net.CIDRMask(0, 32), 0)
+	g.Expect(err).Should(HaveOccurred())
+}
+
+func TestCalculateSubnetIPv4Mapped(t *testing.T) {
+	tests := []struct {
+		description       string
+		cidr              string
+		expectedNetwork   string
+		expectedHostMinIP string
+		expectedHostMaxIP string
+		expectedHostsNum  int
+	}{
+		{
+			description:       "mapped /24",
+			cidr:              "::ffff:10.0.0.0/120",
+			expectedNetwork:   "10.0.0.0/24",
+			expectedHostMinIP: "10.0.0.1",
+			expectedHostMaxIP: "10.0.0.254",
+			expectedHostsNum:  254,
+		},
+		{
+			description:       "mapped single address",
+			cidr:              "::ffff:1.2.3.4/128",
+			expectedNetwork:   "1.2.3.4/32",
+			expectedHostMinIP: "1.2.3.4",
+			expectedHostMaxIP: "1.2.3.4",
+			expectedHostsNum:  1,
+		},
+		{
+			description:       "mask reaching into the IPv6 prefix",
+			cidr:              "::ffff:10.0.0.0/64",
+			expectedNetwork:   "::/64",
+			expectedHostMinIP: "::",
+			expectedHostMaxIP: "::ffff:ffff:ffff:ffff",
+			expectedHostsNum:  math.MaxInt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewWithT(t)
+
+			subnet, err := CalculateSubnet(tt.cidr)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(subnet.NetworkCIDR).To(Equal(tt.cidr))
+			g.Expect(subnet.Network.String()).To(Equal(tt.expectedNetwork))
+			g.Expect(subnet.HostMinIP.String()).To(Equal(tt.expectedHostMinIP))
+			g.Expect(subnet.HostMaxIP.String()).To(Equal(tt.expectedHostMaxIP))
+			g.Expect(subnet.HostsNum).To(Equal(tt.expectedHostsNum))
+		})
+	}
+
+	g := NewWithT(t)
+	overlap, err := Overlaps("::ffff:10.0.0.0/120", "10.0.0.128/25")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(overlap).To(BeTrue())
+	var subnet Subnet
+	g.Expect(json.Unmarshal([]byte(`{"network_cidr":"::ffff:10.0.0.0/120"}`), &subnet)).To(Succeed())
+	g.Expect(subnet.Network.String()).To(Equal("10.0.0.0/24"))
+}
@@ -1,64 +1,153 @@
 package subnets
 
 import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding"
 	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"iter"
+	"math"
+	"math/big"
+	"math/bits"
 	"net"
+	"net/netip"
+	"sort"
 	"strconv"
+	"strings"
 )
 
+// Subnet describes an IPv4 or an IPv6 subnet. Calculations supporting IPv4 only
+// return an error or a zero value like nil, false or an empty string for IPv6 subnets.
 type Subnet struct {
-	NetworkCIDR   string
-	Network       net.IPNet // TODO doubles IP and NetworkMask
-	IP            net.IP
-	NetworkMask   net.IPMask
-	BroadcastIP   net.IP
-	HostMinIP     net.IP
-	HostMaxIP     net.IP
+	NetworkCIDR string
+	Network     net.IPNet // TODO doubles IP and NetworkMask
+	IP          net.IP
+	NetworkMask net.IPMask
+	// WildcardMask is the bitwise complement of NetworkMask as used by Cisco ACLs.
+	WildcardMask net.IPMask
+	// BroadcastIP holds the last address of the block for IPv6 subnets.
+	BroadcastIP net.IP
+	HostMinIP   net.IP
+	HostMaxIP   net.IP
+	// HostsNum and TotalHostsNum are capped at math.MaxInt for large IPv6 subnets
+	// and for large IPv4 subnets on 32-bit platforms.
 	HostsNum      int
 	TotalHostsNum int
 }
 
 func CalculateSubnetsByCIDR(CIDRBlock string, cidr uint32, requestedSubnetCount ...int) ([]*Subnet, error) {
 	// get subnet mask from cidr
-	sourceNet, err := CalculateSubnet(CIDRBlock)
+	sourceNet, err := calculateIPv4Subnet(CIDRBlock)
 	if err != nil {
 		return nil, err
 	}
+	if cidr > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", cidr)
+	}
+	if sourcePrefix := sourceNet.PrefixLen(); int(cidr) <= sourcePrefix {
+		return nil, fmt.Errorf("target prefix /%d must be longer than source prefix /%d", cidr, sourcePrefix)
+	}
 	subnetMask := net.CIDRMask(int(cidr), 32)
 	totalHostCount := uint32(0xFFFFFFFF>>cidr + 1)
 
 	return CalculateSubnets(sourceNet, subnetMask, totalHostCount, requestedSubnetCount...)
 }
 
+// CalculateSubnetsByCIDRPrefixes divides a given CIDR block into subnets with the
+// requested prefix length and returns them as netip.Prefix values.
+func CalculateSubnetsByCIDRPrefixes(parent string, childPrefix uint32) ([]netip.Prefix, error) {
+	parentPrefix, err := netip.ParsePrefix(parent)
+	if err != nil {
+		return nil, err
+	}
+	if !parentPrefix.Addr().Is4() {
+		return nil, fmt.Errorf("parent %s is not an IPv4 CIDR block", parent)
+	}
+	parentPrefix = parentPrefix.Masked()
+	if int(childPrefix) <= parentPrefix.Bits() || childPrefix > 32 {
+		return nil, fmt.Errorf("child prefix /%d out of range for parent %s", childPrefix, parentPrefix)
+	}
+
+	networkBytes := parentPrefix.Addr().As4()
+	networkIPInt := binary.BigEndian.Uint32(networkBytes[:])
+	subnetCount := uint64(1) << (childPrefix - uint32(parentPrefix.Bits()))
+	prefixes := make([]netip.Prefix, 0, subnetCount)
+	for i := uint64(0); i < subnetCount; i++ {
+		binary.BigEndian.PutUint32(networkBytes[:], networkIPInt|uint32(i<<(32-childPrefix)))
+		prefixes = append(prefixes, netip.PrefixFrom(netip.AddrFrom4(networkBytes), int(childPrefix)))
+	}
+	return prefixes, nil
+}
+
 // CalculateSubnetsByHostCount
 func CalculateSubnetsByHostCount(CIDRBlock string, hostNumber uint32, requestedSubnetCount ...int) ([]*Subnet, error) {
-	sourceNet, err := CalculateSubnet(CIDRBlock)
+	sourceNet, err := calculateIPv4Subnet(CIDRBlock)
 	if err != nil {
 		return nil, err
 	}
 	subnetMask, totalSubnetHosts := getSubnetMaskFromAddressBits(hostNumber)
-	return CalculateSubnets(sourceNet, subnetMask, totalSubnetHosts, requestedSubnetCount...)
+	if totalSubnetHosts > math.MaxUint32 {
+		// Only a /0 block holds more addresses than CalculateSubnets can count.
+		var subnets []*Subnet
+		if sourceNet.PrefixLen() == 0 {
+			subnets = []*Subnet{sourceNet.Align()}
+		}
+		if len(requestedSubnetCount) > 0 && requestedSubnetCount[0] > len(subnets) {
+			return nil, fmt.Errorf("requested subnet count %d exceeds maximal possible subnet count %d", requestedSubnetCount[0], len(subnets))
+		}
+		return subnets, nil
+	}
+	return CalculateSubnets(sourceNet, subnetMask, uint32(totalSubnetHosts), requestedSubnetCount...)
 }
 
 // CalculateSubnetsBySubnetCount divides a given CIDR block into a requested number of subnets.
+// Subnets are equally sized blocks, so a subnet number not being a power of two
+// is rounded up to the next power of two, e.g. a /24 divided into 3 subnets results in 4 /26 subnets.
 func CalculateSubnetsBySubnetCount(CIDRBlock string, subnetNumber int, requestedSubnetCount ...int) ([]*Subnet, error) {
-	sourceNet, err := CalculateSubnet(CIDRBlock)
+	sourceNet, err := calculateIPv4Subnet(CIDRBlock)
 	if err != nil {
 		return nil, err
 	}
+	if subnetNumber < 1 {
+		return nil, fmt.Errorf("invalid subnet number %d", subnetNumber)
+	}
 
-	targetSubnetNum := uint32(float64(sourceNet.TotalHostsNum / subnetNumber))
-	netMask, totalHosts := getSubnetMaskFromAddressBits(targetSubnetNum)
-	return CalculateSubnets(sourceNet, netMask, totalHosts, requestedSubnetCount...)
+	subnetOnes := sourceNet.PrefixLen() + bits.Len(uint(subnetNumber-1))
+	if subnetOnes > 32 {
+		return nil, fmt.Errorf("%s can not be divided into %d subnets", CIDRBlock, subnetNumber)
+	}
+	totalHosts := uint64(1) << (32 - subnetOnes)
+	if totalHosts > math.MaxUint32 {
+		// Only the /0 block itself holds more addresses than CalculateSubnets can count.
+		if len(requestedSubnetCount) > 0 && requestedSubnetCount[0] > 1 {
+			return nil, fmt.Errorf("requested subnet count %d exceeds maximal possible subnet count 1", requestedSubnetCount[0])
+		}
+		return []*Subnet{sourceNet.Align()}, nil
+	}
+	netMask := net.CIDRMask(subnetOnes, 32)
+	return CalculateSubnets(sourceNet, netMask, uint32(totalHosts), requestedSubnetCount...)
 }
 
 // CalculateSubnets devides a given subnet in a range of subnets for the required count of contained hosts.
 func CalculateSubnets(sourceNet *Subnet, subnetMask net.IPMask, totalSubnetHosts uint32, requestedSubnetCount ...int) ([]*Subnet, error) {
+	sourceNetIPInt, err := ipv4ToInt(sourceNet.Network.IP)
+	if err != nil {
+		return nil, err
+	}
+	if totalSubnetHosts == 0 {
+		return nil, fmt.Errorf("invalid subnet host count %d", totalSubnetHosts)
+	}
 	expectedNetworkNum := int(float64(sourceNet.TotalHostsNum / int(totalSubnetHosts)))
 	if len(requestedSubnetCount) > 0 {
 		if expectedNetworkNum < requestedSubnetCount[0] {
-			return nil, fmt.Errorf("requested subnet count %d exeeds maximal possible subnet count %d", requestedSubnetCount, expectedNetworkNum)
+			return nil, fmt.Errorf("requested subnet count %d exceeds maximal possible subnet count %d", requestedSubnetCount[0], expectedNetworkNum)
 		}
 		expectedNetworkNum = requestedSubnetCount[0]
 	}
@@ -68,7 +157,7 @@ func CalculateSubnets(sourceNet *Subnet, subnetMask net.IPMask, totalSubnetHosts
 	var subnets []*Subnet
 	for i := 0; i < expectedNetworkNum; i++ {
 		currentSubnetMask := i << addressBits
-		currentSubnetIP := intToIP(ipToInt(sourceNet.IP) | uint32(currentSubnetMask))
+		currentSubnetIP := intToIP(sourceNetIPInt | uint32(currentSubnetMask))
 		currentSubnet, err := CalculateSubnet(fmt.Sprintf("%s/%d", currentSubnetIP.String(), maskOnes))
 		if err != nil {
 			return nil, err
@@ -80,7 +169,7 @@ func CalculateSubnets(sourceNet *Subnet, subnetMask net.IPMask, totalSubnetHosts
 
 // getSubnetMaskFromAddressBits delivers the IPMask and the minimal needed host count
 // for any requested number of hosts contained by a requested subnet.
-func getSubnetMaskFromAddressBits(addressBits uint32) (netMask net.IPMask, totalHostCount uint32) {
+func getSubnetMaskFromAddressBits(addressBits uint32) (netMask net.IPMask, totalHostCount uint64) {
 	maskBytes := make([]byte, 4)
 	binary.BigEndian.PutUint32(maskBytes, addressBits)
 
@@ -98,64 +187,444 @@ func getSubnetMaskFromAddressBits(addressBits uint32) (netMask net.IPMask, total
 		break
 	}
 	netMask = net.CIDRMask(networkMaskOnes, 32)
-	totalHostCount = 0xFFFFFFFF>>networkMaskOnes + 1
+	totalHostCount = uint64(1) << (32 - networkMaskOnes)
 	return netMask, totalHostCount
 }
 
+// prefixForHosts returns the longest prefix length of a subnet providing
+// at least the requested number of usable hosts.
+func prefixForHosts(hosts int) (int, error) {
+	if hosts < 1 || hosts > 0xFFFFFFFF-2 {
+		return 0, fmt.Errorf("invalid host count %d", hosts)
+	}
+	// Network and broadcast address are reserved, the highest host index is hosts+1.
+	netMask, _ := getSubnetMaskFromAddressBits(uint32(hosts + 1))
+	ones, _ := netMask.Size()
+	return ones, nil
+}
+
 // GetHostIPsForSubnet calculates the IP addresses between the
 // minimal and the maximal host address.
 // The network address and the broadcast address are stripped.
 func GetHostIPsForSubnet(CIDRBlock string) ([]net.IP, error) {
+	ipnet, err := calculateIPv4Subnet(CIDRBlock)
+	if err != nil {
+		return nil, err
+	}
+	host := ipToInt(ipnet.HostMinIP)
+	// Fill by index, counting up to the host max would overflow at 255.255.255.255.
+	IPs := make([]net.IP, max(ipnet.HostsNum, 0))
+	for i := range IPs {
+		IPs[i] = intToIP(host + uint32(i))
+	}
+	return IPs, nil
+}
+
+// GetHostIPsForSubnetReversed calculates the IP addresses between the
+// maximal and the minimal host address, starting with the maximal host address.
+// The network address and the broadcast address are stripped.
+func GetHostIPsForSubnetReversed(CIDRBlock string) ([]net.IP, error) {
+	IPs, err := GetHostIPsForSubnet(CIDRBlock)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(IPs)-1; i < j; i, j = i+1, j-1 {
+		IPs[i], IPs[j] = IPs[j], IPs[i]
+	}
+	return IPs, nil
+}
+
+// ForEachHostIP calls fn for each IP address between the minimal and the
+// maximal host address without materializing all of them, until fn returns false.
+// The network address and the broadcast address are stripped.
+func ForEachHostIP(CIDRBlock string, fn func(net.IP) bool) error {
 	ipnet, err := CalculateSubnet(CIDRBlock)
+	if err != nil {
+		return err
+	}
+	for ip := range ipnet.HostIPs() {
+		if !fn(ip) {
+			break
+		}
+	}
+	return nil
+}
+
+// HostRoutes returns a /32 host route subnet for each IP address between
+// the minimal and the maximal host address.
+func HostRoutes(CIDRBlock string) ([]*Subnet, error) {
+	hosts, err := HostInts(CIDRBlock)
 	if err != nil {
 		return nil, err
 	}
+	routes := make([]*Subnet, len(hosts))
+	for i, host := range hosts {
+		routes[i], err = CalculateSubnetFromInt(host, 32)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return routes, nil
+}
+
+// HostInts calculates the host addresses between the minimal and the
+// maximal host address as integers in ascending order.
+// The network address and the broadcast address are stripped.
+func HostInts(CIDRBlock string) ([]uint32, error) {
+	ipnet, err := calculateIPv4Subnet(CIDRBlock)
+	if err != nil {
+		return nil, err
+	}
+	if ipnet.HostsNum < 1 {
+		return []uint32{}, nil
+	}
 	host := ipToInt(ipnet.HostMinIP)
-	lastHost := ipToInt(ipnet.HostMaxIP)
+	hosts := make([]uint32, ipnet.HostsNum)
+	for i := range hosts {
+		hosts[i] = host
+		host++
+	}
+	return hosts, nil
+}
 
-	IPs := make([]net.IP, ipnet.HostsNum)
-	i := 0
-	for host <= lastHost {
+// FilterHosts calculates the host addresses between the minimal and the
+// maximal host address for which keep returns true.
+func FilterHosts(CIDRBlock string, keep func(net.IP) bool) ([]net.IP, error) {
+	hosts, err := HostInts(CIDRBlock)
+	if err != nil {
+		return nil, err
+	}
+	IPs := []net.IP{}
+	for _, host := range hosts {
 		currentIP := intToIP(host)
-		IPs[i] = currentIP
-		host++
-		i++
+		if keep(currentIP) {
+			IPs = append(IPs, currentIP)
+		}
 	}
 	return IPs, nil
 }
 
 func CalculateSubnet(CIDRBlock string) (*Subnet, error) {
+	CIDRBlock = stripLeadingZeros(CIDRBlock)
+	sourceNetStartIP, ipnetwork, err := net.ParseCIDR(CIDRBlock)
+	if err != nil {
+		return nil, err
+	}
+	// IPv4-mapped IPv6 CIDR blocks come with a 16-byte mask, whose last 32 bits
+	// are the IPv4 mask as long as the mask doesn't reach into the IPv6 prefix.
+	networkMaskOnes, maskBits := ipnetwork.Mask.Size()
+	if ipv4MaskOnes := networkMaskOnes - (maskBits - 32); sourceNetStartIP.To4() != nil && ipv4MaskOnes >= 0 {
+		ipnet := newIPv4Subnet(ipToInt(sourceNetStartIP), ipv4MaskOnes)
+		ipnet.NetworkCIDR = CIDRBlock
+		return ipnet, nil
+	}
+
 	ipnet := Subnet{
 		NetworkCIDR: CIDRBlock,
+		Network:     *ipnetwork,
+		IP:          sourceNetStartIP,
+		NetworkMask: ipnetwork.Mask,
 	}
-	sourceNetStartIP, ipnetwork, err := net.ParseCIDR(CIDRBlock)
+	ipnet.WildcardMask = wildcardMask(ipnetwork.Mask)
+	calculateIPv6Subnet(&ipnet)
+	return &ipnet, nil
+}
+
+// wildcardMask returns the bitwise complement of the netmask.
+func wildcardMask(mask net.IPMask) net.IPMask {
+	wildcard := make(net.IPMask, len(mask))
+	for i, maskByte := range mask {
+		wildcard[i] = ^maskByte
+	}
+	return wildcard
+}
+
+// newIPv4Subnet calculates the subnet of an IPv4 address given as 32-bit integer
+// and a prefix length. The host fields are calculated for the aligned network
+// block by HostRange, so the address may be any address of the block.
+func newIPv4Subnet(IPInt uint32, ones int) *Subnet {
+	networkMask := net.CIDRMask(ones, 32)
+	maskInt := ipToInt(net.IP(networkMask))
+	ipnet := &Subnet{
+		NetworkCIDR:  intToIP(IPInt).String() + "/" + strconv.Itoa(ones),
+		Network:      net.IPNet{IP: intToIP(IPInt & maskInt), Mask: networkMask},
+		IP:           intToIP(IPInt),
+		NetworkMask:  networkMask,
+		WildcardMask: wildcardMask(networkMask),
+	}
+	_, broadcastIPInt := ipnet.addressRange()
+	ipnet.BroadcastIP = intToIP(broadcastIPInt)
+	ipnet.HostMinIP, ipnet.HostMaxIP = ipnet.HostRange()
+
+	// Calculate in 64 bits, the 2^32 addresses of a /0 overflow uint32.
+	ipnet.TotalHostsNum = int(min(uint64(1)<<(32-ones), math.MaxInt))
+	ipnet.HostsNum = int(min(uint64(ipToInt(ipnet.HostMaxIP)-ipToInt(ipnet.HostMinIP))+1, math.MaxInt))
+	return ipnet
+}
+
+// calculateIPv6Subnet calculates the address fields of an IPv6 subnet using
+// a 128-bit representation. IPv6 knows no broadcast address, so all addresses
+// of the block are hosts, and BroadcastIP holds the last address of the block.
+func calculateIPv6Subnet(ipnet *Subnet) {
+	networkIPInt := ipToBigInt(ipnet.Network.IP)
+	networkMaskOnes, bits := ipnet.NetworkMask.Size()
+	totalHosts := new(big.Int).Lsh(big.NewInt(1), uint(bits-networkMaskOnes))
+	lastIPInt := new(big.Int).Add(networkIPInt, totalHosts)
+	lastIPInt.Sub(lastIPInt, big.NewInt(1))
+
+	ipnet.TotalHostsNum = math.MaxInt
+	if totalHosts.IsInt64() && totalHosts.Int64() < math.MaxInt {
+		ipnet.TotalHostsNum = int(totalHosts.Int64())
+	}
+	ipnet.HostsNum = ipnet.TotalHostsNum
+
+	ipnet.BroadcastIP = bigIntToIP(lastIPInt)
+	ipnet.HostMinIP = bigIntToIP(networkIPInt)
+	ipnet.HostMaxIP = bigIntToIP(lastIPInt)
+}
+
+// calculateIPv4Subnet calculates a subnet like CalculateSubnet, but returns
+// an error for IPv6 CIDR blocks, for calculations supporting IPv4 only.
+func calculateIPv4Subnet(CIDRBlock string) (*Subnet, error) {
+	ipnet, err := CalculateSubnet(CIDRBlock)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := ipv4ToInt(ipnet.IP); err != nil {
+		return nil, err
+	}
+	return ipnet, nil
+}
+
+// CalculateSubnetFromInt calculates a subnet from a network address given
+// as 32-bit integer and a prefix length.
+func CalculateSubnetFromInt(networkInt uint32, prefix int) (*Subnet, error) {
+	if prefix < 0 || prefix > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", prefix)
+	}
+	return newIPv4Subnet(networkInt, prefix), nil
+}
+
+// CalculateSubnetFromMask calculates a subnet from a dotted-decimal IPv4 address
+// and a dotted-decimal netmask, e.g. 192.168.1.0 255.255.255.0.
+func CalculateSubnetFromMask(ip string, mask string) (*Subnet, error) {
+	parsedMask := net.ParseIP(mask).To4()
+	if parsedMask == nil {
+		return nil, fmt.Errorf("invalid netmask %q", mask)
+	}
+	ones, maskBits := net.IPMask(parsedMask).Size()
+	if maskBits == 0 {
+		return nil, fmt.Errorf("netmask %s is not contiguous", mask)
+	}
+	return calculateIPv4Subnet(fmt.Sprintf("%s/%d", ip, ones))
+}
+
+// stripLeadingZeros removes leading zeros from each octet of a dotted-decimal
+// IPv4 CIDR block, e.g. 010.000.000.000/24 becomes 10.0.0.0/24, since
+// net.ParseCIDR rejects them. The octets are always read as decimal numbers.
+// Anything else than 4 decimal octets is returned unchanged.
+func stripLeadingZeros(CIDRBlock string) string {
+	address, prefix, found := strings.Cut(CIDRBlock, "/")
+	octets := strings.Split(address, ".")
+	if len(octets) != 4 {
+		return CIDRBlock
+	}
+	for i, octet := range octets {
+		if octet == "" || strings.Trim(octet, "0123456789") != "" {
+			return CIDRBlock
+		}
+		octets[i] = strings.TrimLeft(octet, "0")
+		if octets[i] == "" {
+			octets[i] = "0"
+		}
+	}
+	normalized := strings.Join(octets, ".")
+	if found {
+		normalized += "/" + prefix
+	}
+	return normalized
+}
 
-	ipnet.Network = *ipnetwork
-	ipnet.IP = sourceNetStartIP
-	ipnet.NetworkMask = ipnetwork.Mask
+// parseIPv4ToInt parses a dotted-decimal IPv4 address into its integer form.
+func parseIPv4ToInt(ip string) (uint32, error) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return 0, fmt.Errorf("invalid IPv4 address %q", ip)
+	}
+	return ipv4ToInt(parsedIP)
+}
 
-	// Convert IP bytes to int to allow bitwise operations.
-	networkIPInt := ipToInt(sourceNetStartIP)
+// parseIPv4Range parses the start and the end address of an IPv4 range.
+func parseIPv4Range(start, end string) (startIPInt uint32, endIPInt uint32, err error) {
+	startIPInt, err = parseIPv4ToInt(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	endIPInt, err = parseIPv4ToInt(end)
+	if err != nil {
+		return 0, 0, err
+	}
+	if startIPInt > endIPInt {
+		return 0, 0, fmt.Errorf("range start %s is after range end %s", start, end)
+	}
+	return startIPInt, endIPInt, nil
+}
 
-	// Mask with the host part bits for broadcast address.
-	networkMaskOnes, _ := ipnetwork.Mask.Size()
-	subnetIPOnes := 0xFFFFFFFF >> networkMaskOnes
-	broadcastIPInt := networkIPInt | uint32(subnetIPOnes)
+// addressRange returns the first and the last address of the subnet's network block.
+func (s *Subnet) addressRange() (first uint32, last uint32) {
+	ones, _ := s.NetworkMask.Size()
+	first = ipToInt(s.Network.IP)
+	last = first | uint32(0xFFFFFFFF>>ones)
+	return first, last
+}
 
-	hostMinIPInt := networkIPInt | 1
-	hostMaxIPInt := broadcastIPInt &^ 1
+// calculateUsedSubnets calculates the subnets for a list of used CIDR blocks.
+func calculateUsedSubnets(used []string) ([]*Subnet, error) {
+	usedNets := make([]*Subnet, 0, len(used))
+	for _, cidr := range used {
+		usedNet, err := calculateIPv4Subnet(cidr)
+		if err != nil {
+			return nil, err
+		}
+		usedNets = append(usedNets, usedNet)
+	}
+	return usedNets, nil
+}
 
-	ipnet.TotalHostsNum = int(broadcastIPInt - networkIPInt + 1)
-	ipnet.HostsNum = ipnet.TotalHostsNum - 2
+// overlapsAny reports whether the address range from first to last
+// overlaps any of the given subnets.
+func overlapsAny(first, last uint32, subnets []*Subnet) bool {
+	for _, subnet := range subnets {
+		subnetFirst, subnetLast := subnet.addressRange()
+		if first <= subnetLast && subnetFirst <= last {
+			return true
+		}
+	}
+	return false
+}
 
-	// Convert int back to bytes for regular net.IP.
-	ipnet.BroadcastIP = intToIP(broadcastIPInt)
-	ipnet.HostMinIP = intToIP(hostMinIPInt)
-	ipnet.HostMaxIP = intToIP(hostMaxIPInt)
-	return &ipnet, nil
+// coverRange covers the address range from first to last with aligned subnets
+// of the allowed prefix lengths, which must be sorted ascending. The largest
+// allowed subnet fitting at each position is taken. If no allowed subnet fits
+// into the remaining range, the smallest allowed subnet containing the position
+// is used, so the result may cover addresses outside the range.
+func coverRange(first, last uint32, allowed []int) ([]*Subnet, error) {
+	var subnets []*Subnet
+	cursor := uint64(first)
+	for cursor <= uint64(last) {
+		prefix := allowed[len(allowed)-1]
+		network := cursor &^ (uint64(1)<<(32-prefix) - 1)
+		for _, allowedPrefix := range allowed {
+			blockSize := uint64(1) << (32 - allowedPrefix)
+			if cursor%blockSize == 0 && cursor+blockSize-1 <= uint64(last) {
+				prefix = allowedPrefix
+				network = cursor
+				break
+			}
+		}
+		subnet, err := CalculateSubnetFromInt(uint32(network), prefix)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, subnet)
+		cursor = network + uint64(1)<<(32-prefix)
+	}
+	return subnets, nil
+}
+
+// allPrefixes are all IPv4 prefix lengths in ascending order.
+var allPrefixes = func() []int {
+	prefixes := make([]int, 33)
+	for i := range prefixes {
+		prefixes[i] = i
+	}
+	return prefixes
+}()
+
+// rangeToCIDRs returns the minimal list of subnets exactly covering the
+// address range from first to last.
+func rangeToCIDRs(first, last uint32) ([]*Subnet, error) {
+	return coverRange(first, last, allPrefixes)
+}
+
+// ipRange is an inclusive range of IPv4 addresses.
+type ipRange struct {
+	first, last uint32
+}
+
+// size returns the number of addresses of the range.
+func (r ipRange) size() int64 {
+	return int64(r.last) - int64(r.first) + 1
+}
+
+// mergeAddressRanges returns the sorted, merged address ranges covered by the subnets.
+func mergeAddressRanges(subnets []*Subnet) []ipRange {
+	ranges := make([]ipRange, 0, len(subnets))
+	for _, subnet := range subnets {
+		first, last := subnet.addressRange()
+		ranges = append(ranges, ipRange{first: first, last: last})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].first < ranges[j].first
+	})
+
+	var merged []ipRange
+	for _, r := range ranges {
+		if len(merged) > 0 && uint64(r.first) <= uint64(merged[len(merged)-1].last)+1 {
+			merged[len(merged)-1].last = max(merged[len(merged)-1].last, r.last)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// intersectAddressRanges returns the address ranges covered by both sorted,
+// merged lists of address ranges.
+func intersectAddressRanges(a, b []ipRange) []ipRange {
+	var intersection []ipRange
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		first := max(a[i].first, b[j].first)
+		last := min(a[i].last, b[j].last)
+		if first <= last {
+			intersection = append(intersection, ipRange{first: first, last: last})
+		}
+		if a[i].last < b[j].last {
+			i++
+		} else {
+			j++
+		}
+	}
+	return intersection
+}
+
+// subtractAddressRanges returns the parts of the address range r not covered
+// by the sorted, merged list of address ranges.
+func subtractAddressRanges(r ipRange, ranges []ipRange) []ipRange {
+	var remaining []ipRange
+	cursor := uint64(r.first)
+	for _, covered := range ranges {
+		if uint64(covered.last) < cursor || covered.first > r.last {
+			continue
+		}
+		if uint64(covered.first) > cursor {
+			remaining = append(remaining, ipRange{first: uint32(cursor), last: covered.first - 1})
+		}
+		cursor = uint64(covered.last) + 1
+	}
+	if cursor <= uint64(r.last) {
+		remaining = append(remaining, ipRange{first: uint32(cursor), last: r.last})
+	}
+	return remaining
+}
+
+// totalSize returns the number of addresses of all address ranges.
+func totalSize(ranges []ipRange) int64 {
+	total := int64(0)
+	for _, r := range ranges {
+		total += r.size()
+	}
+	return total
 }
 
 func intToIP(intIP uint32) net.IP {
@@ -164,15 +633,1959 @@ func intToIP(intIP uint32) net.IP {
 	return IPBytes
 }
 
+// ipToInt converts an IPv4 address in its 4-byte or 16-byte form to its integer form.
+// The address must be a valid IPv4 address, use ipv4ToInt for unchecked input.
 func ipToInt(netIP net.IP) uint32 {
 	return binary.BigEndian.Uint32(netIP.To4())
 }
 
+// ipv4ToInt converts an IPv4 address to its integer form and returns an error
+// instead of panicking for IPv6 or malformed addresses.
+func ipv4ToInt(netIP net.IP) (uint32, error) {
+	IPBytes := netIP.To4()
+	if IPBytes == nil {
+		return 0, fmt.Errorf("address %s is not a valid IPv4 address", netIP)
+	}
+	return binary.BigEndian.Uint32(IPBytes), nil
+}
+
+func ipToBigInt(netIP net.IP) *big.Int {
+	return new(big.Int).SetBytes(netIP.To16())
+}
+
+func bigIntToIP(intIP *big.Int) net.IP {
+	IPBytes := make([]byte, net.IPv6len)
+	intIP.FillBytes(IPBytes)
+	return IPBytes
+}
+
 func (s *Subnet) String() string {
-	return s.IP.String() + "/" + s.NetworkMask.String() + "\n" +
+	return s.IP.String() + "/" + s.MaskString() + "\n" +
+		"Wildcard:    " + net.IP(s.WildcardMask).String() + "\n" +
 		"HostMin:     " + s.HostMinIP.String() + "\n" +
 		"HostMax:     " + s.HostMaxIP.String() + "\n" +
 		"Broadcast:   " + s.BroadcastIP.String() + "\n" +
 		"Hosts:       " + strconv.Itoa(s.HostsNum) + "\n" +
 		"Hosts total: " + strconv.Itoa(s.TotalHostsNum) + "\n"
 }
+
+// MaskString returns the subnet's netmask in dotted-decimal form, e.g. 255.255.255.0.
+// The hexadecimal form is still provided by NetworkMask.String().
+func (s *Subnet) MaskString() string {
+	return net.IP(s.NetworkMask).String()
+}
+
+// ID returns a compact, sortable key for the subnet combining the
+// network address and the prefix length (network<<8 | prefix).
+// It returns 0 for IPv6 subnets.
+func (s *Subnet) ID() uint64 {
+	networkIPInt, err := ipv4ToInt(s.Network.IP)
+	if err != nil {
+		return 0
+	}
+	ones, _ := s.NetworkMask.Size()
+	return uint64(networkIPInt)<<8 | uint64(ones)
+}
+
+// Align returns a new subnet for the aligned network block the subnet's IP
+// belongs to, e.g. 10.0.0.130/25 aligns to 10.0.0.128/25.
+// The receiver is left untouched. It returns nil for a subnet without network,
+// like the zero value.
+func (s *Subnet) Align() *Subnet {
+	ones, _ := s.Network.Mask.Size()
+	if networkIPInt, err := ipv4ToInt(s.Network.IP); err == nil {
+		return newIPv4Subnet(networkIPInt, ones)
+	}
+	if s.Network.IP.To16() == nil {
+		return nil
+	}
+	network := net.IPNet{
+		IP:   append(net.IP(nil), s.Network.IP...),
+		Mask: append(net.IPMask(nil), s.Network.Mask...),
+	}
+	aligned := &Subnet{
+		NetworkCIDR:  network.String(),
+		Network:      network,
+		IP:           network.IP,
+		NetworkMask:  network.Mask,
+		WildcardMask: wildcardMask(network.Mask),
+	}
+	calculateIPv6Subnet(aligned)
+	return aligned
+}
+
+// LongestPrefixMatch returns the most specific route of the given route
+// CIDR blocks containing the destination IP.
+func LongestPrefixMatch(routes []string, dest string) (*Subnet, error) {
+	destIP := net.ParseIP(dest)
+	if destIP == nil {
+		return nil, fmt.Errorf("invalid destination IP %q", dest)
+	}
+	var bestRoute *Subnet
+	bestOnes := -1
+	for _, route := range routes {
+		routeNet, err := CalculateSubnet(route)
+		if err != nil {
+			return nil, err
+		}
+		if !routeNet.Network.Contains(destIP) {
+			continue
+		}
+		ones, _ := routeNet.NetworkMask.Size()
+		if ones > bestOnes {
+			bestRoute = routeNet
+			bestOnes = ones
+		}
+	}
+	if bestRoute == nil {
+		return nil, fmt.Errorf("no route matches destination %s", dest)
+	}
+	return bestRoute, nil
+}
+
+// EfficiencyForPrefix returns the ratio of the needed hosts to the usable
+// hosts of a subnet with the given prefix length.
+// It returns 0 for prefix lengths out of range.
+func EfficiencyForPrefix(hostsNeeded int, prefix int) float64 {
+	if prefix < 0 || prefix > 32 {
+		return 0
+	}
+	usableHosts := int(uint64(1) << (32 - prefix))
+	if prefix < 31 {
+		usableHosts -= 2
+	}
+	return float64(hostsNeeded) / float64(usableHosts)
+}
+
+// SplitByRatio splits a parent CIDR block into two unequally sized children.
+// The right child always is the upper half of the parent, the left child is the
+// first block of the lower half with a prefix length leftBits longer than the parent,
+// e.g. a /24 with leftBits 2 results in a /26 (1/4) and a /25 (1/2).
+// Both children must be aligned CIDR blocks, so for leftBits > 1 the addresses
+// between the left child and the upper half stay unallocated.
+func SplitByRatio(parent string, leftBits int) ([2]*Subnet, error) {
+	var children [2]*Subnet
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return children, err
+	}
+	parentOnes, _ := parentNet.NetworkMask.Size()
+	if leftBits < 1 || parentOnes+leftBits > 32 {
+		return children, fmt.Errorf("left bits %d out of range for parent prefix /%d", leftBits, parentOnes)
+	}
+
+	networkIPInt := ipToInt(parentNet.Network.IP)
+	children[0], err = CalculateSubnetFromInt(networkIPInt, parentOnes+leftBits)
+	if err != nil {
+		return children, err
+	}
+	upperHalfIPInt := networkIPInt | uint32(1)<<(31-parentOnes)
+	children[1], err = CalculateSubnetFromInt(upperHalfIPInt, parentOnes+1)
+	if err != nil {
+		return children, err
+	}
+	return children, nil
+}
+
+// HostOffset returns the 0-based offset of the IP from the IPv4 subnet's network address.
+func (s *Subnet) HostOffset(ip net.IP) (int, error) {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return 0, err
+	}
+	if ip.To4() == nil || !s.Network.Contains(ip) {
+		return 0, fmt.Errorf("IP %s is not contained in subnet %s", ip, s.Network.String())
+	}
+	return int(ipToInt(ip) - ipToInt(s.Network.IP)), nil
+}
+
+// BINDGenerate returns a BIND $GENERATE directive creating the PTR records
+// for all hosts of the subnet, e.g. "$GENERATE 1-254 $ PTR host-$.example.com."
+// for a /24. The directive is meant for the subnet's in-addr.arpa zone,
+// so subnets with a prefix shorter than /24 are not supported.
+func (s *Subnet) BINDGenerate(domainTemplate string) (string, error) {
+	if domainTemplate == "" {
+		return "", fmt.Errorf("domain template must not be empty")
+	}
+	if _, err := ipv4ToInt(s.IP); err != nil {
+		return "", err
+	}
+	ones, _ := s.NetworkMask.Size()
+	if ones < 24 {
+		return "", fmt.Errorf("a single $GENERATE directive requires a prefix of at least /24, got /%d", ones)
+	}
+	if s.HostsNum < 1 {
+		return "", fmt.Errorf("subnet %s has no usable hosts", s.Network.String())
+	}
+	firstHost := s.HostMinIP.To4()[3]
+	lastHost := s.HostMaxIP.To4()[3]
+	return fmt.Sprintf("$GENERATE %d-%d $ PTR %s", firstHost, lastHost, domainTemplate), nil
+}
+
+// TotalChildrenAcrossParents sums up the number of subnets with the given
+// child prefix length fitting into each of the parent CIDR blocks.
+func TotalChildrenAcrossParents(parents []string, childPrefix int) (int, error) {
+	total := 0
+	for _, parent := range parents {
+		parentNet, err := calculateIPv4Subnet(parent)
+		if err != nil {
+			return 0, err
+		}
+		parentOnes, _ := parentNet.NetworkMask.Size()
+		if childPrefix < parentOnes || childPrefix > 32 {
+			return 0, fmt.Errorf("child prefix /%d out of range for parent %s", childPrefix, parent)
+		}
+		total += 1 << (childPrefix - parentOnes)
+	}
+	return total, nil
+}
+
+// CanAggregate reports whether the subnets a and b are the two halves
+// of a common supernet and returns this supernet if so.
+// IPv6 subnets are never aggregated.
+func CanAggregate(a, b *Subnet) (bool, *Subnet) {
+	aNetworkIPInt, aErr := ipv4ToInt(a.Network.IP)
+	bNetworkIPInt, bErr := ipv4ToInt(b.Network.IP)
+	if aErr != nil || bErr != nil {
+		return false, nil
+	}
+	aOnes, _ := a.NetworkMask.Size()
+	bOnes, _ := b.NetworkMask.Size()
+	if aOnes != bOnes || aOnes == 0 {
+		return false, nil
+	}
+	siblingBit := uint32(1) << (32 - aOnes)
+	if aNetworkIPInt^bNetworkIPInt != siblingBit {
+		return false, nil
+	}
+	supernet, err := CalculateSubnetFromInt(aNetworkIPInt&^siblingBit, aOnes-1)
+	if err != nil {
+		return false, nil
+	}
+	return true, supernet
+}
+
+// StepSize returns the interesting octet (1-4) of a netmask with the given prefix
+// length and the block size subnets advance by within this octet,
+// e.g. /26 results in octet 4 and a step of 64.
+// For prefix lengths out of range 0 and 0 are returned.
+func StepSize(prefix int) (octet int, step int) {
+	if prefix < 0 || prefix > 32 {
+		return 0, 0
+	}
+	octet = (prefix + 7) / 8
+	if octet == 0 {
+		octet = 1
+	}
+	maskBitsInOctet := prefix - (octet-1)*8
+	return octet, 256 >> maskBitsInOctet
+}
+
+// ParseAll calculates the subnets for all given CIDR blocks. It does not stop
+// at invalid CIDR blocks but collects an error per failing line instead,
+// so a whole list can be validated in one pass.
+func ParseAll(cidrs []string) (subnets []*Subnet, errs []error) {
+	for i, cidr := range cidrs {
+		subnet, err := CalculateSubnet(cidr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", i+1, err))
+			continue
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, errs
+}
+
+// FitsWithinParent reports whether subnets with the given prefix lengths fit
+// into the parent CIDR block without actually allocating them.
+// Placing the subnets largest first keeps every subnet aligned, so they
+// fit as long as their summed up addresses don't exceed the parent's addresses.
+func FitsWithinParent(parent string, childPrefixes []int) (bool, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return false, err
+	}
+	parentOnes, _ := parentNet.NetworkMask.Size()
+	requiredAddresses := uint64(0)
+	for _, childPrefix := range childPrefixes {
+		if childPrefix < parentOnes || childPrefix > 32 {
+			return false, fmt.Errorf("child prefix /%d out of range for parent %s", childPrefix, parent)
+		}
+		requiredAddresses += uint64(1) << (32 - childPrefix)
+	}
+	return requiredAddresses <= uint64(1)<<(32-parentOnes), nil
+}
+
+// Widen returns the parent subnet with a prefix length one shorter than the
+// subnet's prefix length and the number of addresses gained by widening.
+func (s *Subnet) Widen() (parent *Subnet, addressGain int, err error) {
+	networkIPInt, err := ipv4ToInt(s.Network.IP)
+	if err != nil {
+		return nil, 0, err
+	}
+	ones, _ := s.NetworkMask.Size()
+	if ones == 0 {
+		return nil, 0, fmt.Errorf("subnet %s can not be widened", s.Network.String())
+	}
+	parent, err = CalculateSubnetFromInt(networkIPInt, ones-1)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Widening adds the address space of the sibling subnet.
+	return parent.Align(), 1 << (32 - ones), nil
+}
+
+// HostForKey maps the key deterministically to one of the subnet's host addresses
+// by hashing it, so the same key always results in the same IP.
+// It returns nil if the subnet has no usable hosts and for IPv6 subnets.
+func (s *Subnet) HostForKey(key string) net.IP {
+	hostMin, err := ipv4ToInt(s.HostMinIP)
+	if err != nil || s.HostsNum < 1 {
+		return nil
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	hostOffset := hash.Sum64() % uint64(s.HostsNum)
+	return intToIP(hostMin + uint32(hostOffset))
+}
+
+// CalculateSubnetsNamed divides a given CIDR block into subnets with the
+// requested prefix length and assigns them in order to the given names.
+func CalculateSubnetsNamed(parent string, childPrefix int, names []string) (map[string]*Subnet, error) {
+	if childPrefix < 0 || childPrefix > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", childPrefix)
+	}
+	subnets, err := CalculateSubnetsByCIDR(parent, uint32(childPrefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > len(subnets) {
+		return nil, fmt.Errorf("%d names exceed the %d subnets of %s", len(names), len(subnets), parent)
+	}
+	namedSubnets := make(map[string]*Subnet, len(names))
+	for i, name := range names {
+		if _, ok := namedSubnets[name]; ok {
+			return nil, fmt.Errorf("duplicate subnet name %q", name)
+		}
+		namedSubnets[name] = subnets[i]
+	}
+	return namedSubnets, nil
+}
+
+// HostSample returns the first n and the last n host addresses of the subnet.
+// If the subnet has less than 2n hosts, the last addresses only contain
+// the hosts not already returned as first addresses.
+// It returns no addresses for IPv6 subnets.
+func (s *Subnet) HostSample(n int) (first []net.IP, last []net.IP) {
+	hostMin, err := ipv4ToInt(s.HostMinIP)
+	if err != nil || n < 1 || s.HostsNum < 1 {
+		return nil, nil
+	}
+	firstNum := min(n, s.HostsNum)
+	lastNum := min(n, s.HostsNum-firstNum)
+
+	for i := 0; i < firstNum; i++ {
+		first = append(first, intToIP(hostMin+uint32(i)))
+	}
+	hostMax := ipToInt(s.HostMaxIP)
+	for i := lastNum - 1; i >= 0; i-- {
+		last = append(last, intToIP(hostMax-uint32(i)))
+	}
+	return first, last
+}
+
+// RangeCrossesBoundary reports whether the IP range from start to end spans
+// more than one subnet with the given prefix length.
+func RangeCrossesBoundary(start, end string, prefix int) (bool, error) {
+	if prefix < 0 || prefix > 32 {
+		return false, fmt.Errorf("invalid prefix length %d", prefix)
+	}
+	startIPInt, endIPInt, err := parseIPv4Range(start, end)
+	if err != nil {
+		return false, err
+	}
+	mask := ipToInt(net.IP(net.CIDRMask(prefix, 32)))
+	return startIPInt&mask != endIPInt&mask, nil
+}
+
+// MikroTikAddress returns a RouterOS command assigning the subnet's first
+// host address to the given interface, using the /ipv6 menu for IPv6 subnets.
+func (s *Subnet) MikroTikAddress(iface string) string {
+	menu := "ip"
+	if s.HostMinIP.To4() == nil {
+		menu = "ipv6"
+	}
+	ones, _ := s.NetworkMask.Size()
+	return fmt.Sprintf("/%s address add address=%s/%d interface=%s", menu, s.HostMinIP.String(), ones, iface)
+}
+
+// Summary describes a list of allocated subnets.
+type Summary struct {
+	Count          int
+	TotalAddresses int64
+	TotalUsable    int64
+	// Prefixes maps each prefix length to the number of subnets having it.
+	Prefixes map[int]int
+}
+
+// AllocationSummary summarizes the given subnets.
+// Totals exceeding int64, e.g. for large IPv6 subnets, are capped at math.MaxInt64.
+func AllocationSummary(subnets []*Subnet) Summary {
+	summary := Summary{
+		Count:    len(subnets),
+		Prefixes: map[int]int{},
+	}
+	for _, subnet := range subnets {
+		ones, _ := subnet.NetworkMask.Size()
+		summary.TotalAddresses = addCapped(summary.TotalAddresses, subnet.AddressCount())
+		if subnet.HostsNum > 0 {
+			summary.TotalUsable = addCapped(summary.TotalUsable, uint64(subnet.HostsNum))
+		}
+		summary.Prefixes[ones]++
+	}
+	return summary
+}
+
+// addCapped returns the non-negative sum of total and n, capped at math.MaxInt64.
+func addCapped(total int64, n uint64) int64 {
+	if n > uint64(math.MaxInt64-total) {
+		return math.MaxInt64
+	}
+	return total + int64(n)
+}
+
+// FreeNeighbors returns the blocks of the subnet's size immediately before and after
+// the subnet within the parent CIDR block, if they don't overlap any used block.
+// A neighbor that is used or outside the parent is returned as nil.
+func FreeNeighbors(subnet string, used []string, parent string) (before *Subnet, after *Subnet, err error) {
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, nil, err
+	}
+	usedNets, err := calculateUsedSubnets(used)
+	if err != nil {
+		return nil, nil, err
+	}
+	first, last := subnetNet.addressRange()
+	parentFirst, parentLast := parentNet.addressRange()
+	if first < parentFirst || last > parentLast {
+		return nil, nil, fmt.Errorf("subnet %s is not contained in parent %s", subnet, parent)
+	}
+
+	ones, _ := subnetNet.NetworkMask.Size()
+	size := last - first
+	if first > parentFirst && !overlapsAny(first-size-1, first-1, usedNets) {
+		before, err = CalculateSubnetFromInt(first-size-1, ones)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if last < parentLast && !overlapsAny(last+1, last+size+1, usedNets) {
+		after, err = CalculateSubnetFromInt(last+1, ones)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return before, after, nil
+}
+
+// IsProperBlock reports whether the subnet's IP is the network address,
+// i.e. no host bits are set.
+func (s *Subnet) IsProperBlock() bool {
+	return s.IP.Equal(s.Network.IP)
+}
+
+// ChildPrefixForHosts returns the longest prefix length of a subnet providing
+// at least the requested number of usable hosts within the parent CIDR block.
+func ChildPrefixForHosts(parent string, hosts int) (int, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return 0, err
+	}
+	childOnes, err := prefixForHosts(hosts)
+	if err != nil {
+		return 0, err
+	}
+	parentOnes, _ := parentNet.NetworkMask.Size()
+	if childOnes < parentOnes {
+		return 0, fmt.Errorf("%d hosts require a /%d which does not fit into parent %s", hosts, childOnes, parent)
+	}
+	return childOnes, nil
+}
+
+var (
+	_ encoding.BinaryMarshaler   = (*Subnet)(nil)
+	_ encoding.BinaryUnmarshaler = (*Subnet)(nil)
+)
+
+// MarshalBinary encodes the subnet as 4-byte network address followed by
+// 1 byte prefix length.
+func (s *Subnet) MarshalBinary() ([]byte, error) {
+	networkIP := s.Network.IP.To4()
+	if networkIP == nil {
+		return nil, fmt.Errorf("subnet %s is not an IPv4 subnet", s.Network.String())
+	}
+	ones, _ := s.NetworkMask.Size()
+	return append(append([]byte{}, networkIP...), byte(ones)), nil
+}
+
+// UnmarshalBinary decodes a subnet encoded by MarshalBinary and recalculates
+// all other fields.
+func (s *Subnet) UnmarshalBinary(data []byte) error {
+	if len(data) != 5 {
+		return fmt.Errorf("invalid binary subnet length %d, expected 5 bytes", len(data))
+	}
+	subnet, err := CalculateSubnetFromInt(binary.BigEndian.Uint32(data[:4]), int(data[4]))
+	if err != nil {
+		return err
+	}
+	*s = *subnet
+	return nil
+}
+
+// CoveringSubnets returns all aligned subnets with the given prefix length
+// intersecting the IP range from start to end.
+func CoveringSubnets(start, end string, prefix int) ([]*Subnet, error) {
+	if prefix < 0 || prefix > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", prefix)
+	}
+	startIPInt, endIPInt, err := parseIPv4Range(start, end)
+	if err != nil {
+		return nil, err
+	}
+	mask := ipToInt(net.IP(net.CIDRMask(prefix, 32)))
+	blockSize := uint64(1) << (32 - prefix)
+
+	var subnets []*Subnet
+	for network := uint64(startIPInt & mask); network <= uint64(endIPInt); network += blockSize {
+		subnet, err := CalculateSubnetFromInt(uint32(network), prefix)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// Fingerprint returns a short stable hash of the subnet's canonical network CIDR,
+// so differently notated CIDR blocks of the same network share a fingerprint.
+func (s *Subnet) Fingerprint() string {
+	sum := sha256.Sum256([]byte(s.Network.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// RangeToAllowedCIDRs covers the IP range from start to end with subnets
+// using only the allowed prefix lengths, preferring the largest subnets.
+// If the range can't be expressed exactly with the allowed prefix lengths,
+// the outermost subnets cover addresses outside the range.
+func RangeToAllowedCIDRs(start, end string, allowed []int) ([]*Subnet, error) {
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("no allowed prefix lengths given")
+	}
+	for _, prefix := range allowed {
+		if prefix < 0 || prefix > 32 {
+			return nil, fmt.Errorf("invalid prefix length %d", prefix)
+		}
+	}
+	startIPInt, endIPInt, err := parseIPv4Range(start, end)
+	if err != nil {
+		return nil, err
+	}
+	sortedAllowed := append([]int{}, allowed...)
+	sort.Ints(sortedAllowed)
+	return coverRange(startIPInt, endIPInt, sortedAllowed)
+}
+
+// specialAddressBlocks are the IPv4 blocks not usable for unicast addressing.
+var specialAddressBlocks = []struct {
+	first, last uint32
+	addressType string
+}{
+	{first: 0x00000000, last: 0x00FFFFFF, addressType: "reserved"},  // 0.0.0.0/8 "this network"
+	{first: 0x7F000000, last: 0x7FFFFFFF, addressType: "reserved"},  // 127.0.0.0/8 loopback
+	{first: 0xE0000000, last: 0xEFFFFFFF, addressType: "multicast"}, // 224.0.0.0/4
+	{first: 0xF0000000, last: 0xFFFFFFFF, addressType: "reserved"},  // 240.0.0.0/4 incl. limited broadcast
+}
+
+// AddressType returns "unicast", "multicast" or "reserved" if the whole subnet
+// falls into one of these categories, and "mixed" otherwise.
+// Reserved are 0.0.0.0/8, 127.0.0.0/8 and 240.0.0.0/4.
+// It returns an empty string for IPv6 subnets.
+func (s *Subnet) AddressType() string {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return ""
+	}
+	first, last := s.addressRange()
+	for _, block := range specialAddressBlocks {
+		if first >= block.first && last <= block.last {
+			return block.addressType
+		}
+		if first <= block.last && block.first <= last {
+			return "mixed"
+		}
+	}
+	return "unicast"
+}
+
+// NextFreeSubnetOfSize returns the first subnet with the new prefix length within
+// the parent CIDR block whose network address is after the given address
+// and which doesn't overlap any used CIDR block.
+func NextFreeSubnetOfSize(parent string, used []string, newPrefix int, after string) (*Subnet, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	parentOnes, _ := parentNet.NetworkMask.Size()
+	if newPrefix < parentOnes || newPrefix > 32 {
+		return nil, fmt.Errorf("prefix /%d out of range for parent %s", newPrefix, parent)
+	}
+	afterIPInt, err := parseIPv4ToInt(after)
+	if err != nil {
+		return nil, err
+	}
+	usedNets, err := calculateUsedSubnets(used)
+	if err != nil {
+		return nil, err
+	}
+
+	parentFirst, parentLast := parentNet.addressRange()
+	blockSize := uint64(1) << (32 - newPrefix)
+	candidate := uint64(parentFirst)
+	if uint64(afterIPInt) >= candidate {
+		candidate = (uint64(afterIPInt)/blockSize + 1) * blockSize
+	}
+	for ; candidate+blockSize-1 <= uint64(parentLast); candidate += blockSize {
+		if !overlapsAny(uint32(candidate), uint32(candidate+blockSize-1), usedNets) {
+			return CalculateSubnetFromInt(uint32(candidate), newPrefix)
+		}
+	}
+	return nil, fmt.Errorf("no free /%d after %s in %s", newPrefix, after, parent)
+}
+
+// HostsHuman returns the number of usable hosts in a human-readable form,
+// e.g. "254 hosts" for a /24, "1 host" for a /32 or "~16.8M hosts" for a /8.
+// Counts from 1000 on are rounded to one decimal with an SI suffix. The suffix
+// is chosen after rounding, so 999,950 hosts result in "~1M hosts".
+// It returns an empty string for IPv6 subnets, whose host counts exceed HostsNum.
+func (s *Subnet) HostsHuman() string {
+	// Subnets without network, like the zero value, are described by HostsNum alone.
+	if s.Network.IP != nil && s.Network.IP.To4() == nil {
+		return ""
+	}
+	hosts := max(s.HostsNum, 0)
+	if hosts == 1 {
+		return "1 host"
+	}
+	if hosts < 1000 {
+		return strconv.Itoa(hosts) + " hosts"
+	}
+	siSuffixes := []string{"k", "M", "G", "T", "P", "E"}
+	scaled := float64(hosts) / 1000
+	suffix := 0
+	// Move on to the next suffix if rounding reaches 1000, e.g. for 999.95k.
+	for suffix < len(siSuffixes)-1 && math.Round(scaled*10)/10 >= 1000 {
+		scaled /= 1000
+		suffix++
+	}
+	rounded := strings.TrimSuffix(strconv.FormatFloat(scaled, 'f', 1, 64), ".0")
+	return "~" + rounded + siSuffixes[suffix] + " hosts"
+}
+
+// AllSameSize reports whether all subnets share the same prefix length and
+// returns this prefix length. An empty list is not considered same sized.
+func AllSameSize(subnets []*Subnet) (bool, int) {
+	if len(subnets) == 0 {
+		return false, 0
+	}
+	prefix, _ := subnets[0].NetworkMask.Size()
+	for _, subnet := range subnets[1:] {
+		if ones, _ := subnet.NetworkMask.Size(); ones != prefix {
+			return false, 0
+		}
+	}
+	return true, prefix
+}
+
+// GridPosition returns the row and column of the subnet within a grid of all
+// same sized subnets of its parent block with the given prefix length,
+// e.g. the 256 /24 subnets of a /16 form a 16x16 grid.
+// Grids with an odd number of bits are twice as wide as high.
+func (s *Subnet) GridPosition(parentPrefix int) (row, col int, err error) {
+	networkIPInt, err := ipv4ToInt(s.Network.IP)
+	if err != nil {
+		return 0, 0, err
+	}
+	ones, _ := s.NetworkMask.Size()
+	if parentPrefix < 0 || parentPrefix > ones {
+		return 0, 0, fmt.Errorf("parent prefix /%d out of range for subnet %s", parentPrefix, s.Network.String())
+	}
+	childBits := ones - parentPrefix
+	parentMask := ipToInt(net.IP(net.CIDRMask(parentPrefix, 32)))
+	index := int(uint64(networkIPInt&^parentMask) >> (32 - ones))
+	colBits := (childBits + 1) / 2
+	return index >> colBits, index & (1<<colBits - 1), nil
+}
+
+// AllocateVLSMPacked allocates a subnet for each of the requested host counts
+// within the parent CIDR block, in the order of the requests.
+// Each subnet is taken from the smallest free block it fits into (best fit),
+// splitting this block as required, which keeps larger free blocks intact
+// for later requests and minimizes fragmentation.
+func AllocateVLSMPacked(parent string, requests []int) ([]*Subnet, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make([]int, len(requests))
+	for i, hosts := range requests {
+		prefixes[i], err = prefixForHosts(hosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return allocateBestFit(parentNet, prefixes)
+}
+
+// allocateBestFit allocates a subnet for each of the prefix lengths within the
+// parent subnet, in the given order, taking each from the smallest fitting free block.
+func allocateBestFit(parentNet *Subnet, prefixes []int) ([]*Subnet, error) {
+	parentFirst, _ := parentNet.addressRange()
+	parentOnes, _ := parentNet.NetworkMask.Size()
+
+	type block struct {
+		network uint32
+		prefix  int
+	}
+	freeBlocks := []block{{network: parentFirst, prefix: parentOnes}}
+	subnets := make([]*Subnet, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix < 0 || prefix > 32 {
+			return nil, fmt.Errorf("invalid prefix length %d", prefix)
+		}
+		bestFit := -1
+		for i, free := range freeBlocks {
+			if free.prefix > prefix {
+				continue
+			}
+			if bestFit < 0 || free.prefix > freeBlocks[bestFit].prefix ||
+				free.prefix == freeBlocks[bestFit].prefix && free.network < freeBlocks[bestFit].network {
+				bestFit = i
+			}
+		}
+		if bestFit < 0 {
+			return nil, fmt.Errorf("no free /%d left in %s", prefix, parentNet.Network.String())
+		}
+
+		allocated := freeBlocks[bestFit]
+		freeBlocks = append(freeBlocks[:bestFit], freeBlocks[bestFit+1:]...)
+		// Split the block, keeping the lower half and freeing the upper half.
+		for allocated.prefix < prefix {
+			allocated.prefix++
+			freeBlocks = append(freeBlocks, block{
+				network: allocated.network | uint32(1)<<(32-allocated.prefix),
+				prefix:  allocated.prefix,
+			})
+		}
+		subnet, err := CalculateSubnetFromInt(allocated.network, allocated.prefix)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// CanWidenTo reports whether the subnet can be widened to the given prefix length
+// without overlapping any used CIDR block. Used blocks within the subnet itself,
+// like the subnet, are ignored.
+func CanWidenTo(subnet string, prefix int, used []string) (bool, error) {
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return false, err
+	}
+	ones, _ := subnetNet.NetworkMask.Size()
+	if prefix < 0 || prefix > ones {
+		return false, fmt.Errorf("prefix /%d out of range for widening %s", prefix, subnet)
+	}
+	usedNets, err := calculateUsedSubnets(used)
+	if err != nil {
+		return false, err
+	}
+
+	first, last := subnetNet.addressRange()
+	widenedMask := ipToInt(net.IP(net.CIDRMask(prefix, 32)))
+	widenedFirst := first & widenedMask
+	widenedLast := widenedFirst | ^widenedMask
+	for _, usedNet := range usedNets {
+		usedFirst, usedLast := usedNet.addressRange()
+		if usedFirst >= first && usedLast <= last {
+			continue
+		}
+		if usedFirst <= widenedLast && widenedFirst <= usedLast {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// MapVLANsToSubnets divides a given CIDR block into subnets with the requested
+// prefix length and assigns sequential VLAN IDs starting at vlanStart to them.
+func MapVLANsToSubnets(parent string, childPrefix int, vlanStart int) (map[int]*Subnet, error) {
+	if childPrefix < 0 || childPrefix > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", childPrefix)
+	}
+	subnets, err := CalculateSubnetsByCIDR(parent, uint32(childPrefix))
+	if err != nil {
+		return nil, err
+	}
+	// VLAN IDs 0 and 4095 are reserved.
+	if vlanStart < 1 || vlanStart+len(subnets)-1 > 4094 {
+		return nil, fmt.Errorf("VLAN IDs %d-%d exceed the valid range 1-4094", vlanStart, vlanStart+len(subnets)-1)
+	}
+	vlanSubnets := make(map[int]*Subnet, len(subnets))
+	for i, subnet := range subnets {
+		vlanSubnets[vlanStart+i] = subnet
+	}
+	return vlanSubnets, nil
+}
+
+// ComplementWithin returns the minimal list of subnets covering the address
+// space of the parent CIDR block without the subnet.
+func ComplementWithin(subnet, parent string) ([]*Subnet, error) {
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return nil, err
+	}
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	first, last := subnetNet.addressRange()
+	parentFirst, parentLast := parentNet.addressRange()
+	if first < parentFirst || last > parentLast {
+		return nil, fmt.Errorf("subnet %s is not contained in parent %s", subnet, parent)
+	}
+
+	var complement []*Subnet
+	if first > parentFirst {
+		before, err := rangeToCIDRs(parentFirst, first-1)
+		if err != nil {
+			return nil, err
+		}
+		complement = append(complement, before...)
+	}
+	if last < parentLast {
+		after, err := rangeToCIDRs(last+1, parentLast)
+		if err != nil {
+			return nil, err
+		}
+		complement = append(complement, after...)
+	}
+	return complement, nil
+}
+
+// MeetsMinimumSize reports whether the subnet is at least as large as a subnet
+// with the given minimal prefix length, i.e. its prefix length is not longer.
+func (s *Subnet) MeetsMinimumSize(minPrefix int) bool {
+	ones, _ := s.NetworkMask.Size()
+	return ones <= minPrefix
+}
+
+// UniformPrefixFor returns the longest prefix length of a subnet providing
+// enough usable hosts for the largest of the host counts,
+// so all requirements can be served by equally sized subnets.
+func UniformPrefixFor(counts []int) (int, error) {
+	if len(counts) == 0 {
+		return 0, fmt.Errorf("no host counts given")
+	}
+	maxCount := counts[0]
+	for _, count := range counts[1:] {
+		maxCount = max(maxCount, count)
+	}
+	return prefixForHosts(maxCount)
+}
+
+// subnetBits returns the bits of the subnet's network address between the
+// parent prefix length and the subnet's prefix length and their mask.
+// It is not ok for IPv6 subnets.
+func (s *Subnet) subnetBits(parentPrefix int) (bits uint32, mask uint32, ok bool) {
+	networkIPInt, err := ipv4ToInt(s.Network.IP)
+	if err != nil {
+		return 0, 0, false
+	}
+	ones, _ := s.NetworkMask.Size()
+	if parentPrefix < 0 || parentPrefix >= ones {
+		return 0, 0, false
+	}
+	mask = ipToInt(net.IP(s.NetworkMask)) &^ ipToInt(net.IP(net.CIDRMask(parentPrefix, 32)))
+	return networkIPInt & mask, mask, true
+}
+
+// IsSubnetZero reports whether the subnet is the first subnet of its parent block
+// with the given prefix length, having all subnet bits unset.
+func (s *Subnet) IsSubnetZero(parentPrefix int) bool {
+	bits, _, ok := s.subnetBits(parentPrefix)
+	return ok && bits == 0
+}
+
+// IsAllOnesSubnet reports whether the subnet is the last subnet of its parent block
+// with the given prefix length, having all subnet bits set.
+func (s *Subnet) IsAllOnesSubnet(parentPrefix int) bool {
+	bits, mask, ok := s.subnetBits(parentPrefix)
+	return ok && bits == mask
+}
+
+// UtilizationDelta compares two snapshots of used CIDR blocks within the parent CIDR block
+// and returns the number of addresses freed and newly consumed between them.
+func UtilizationDelta(before, after []string, parent string) (freedAddresses int64, consumedAddresses int64, err error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return 0, 0, err
+	}
+	beforeNets, err := calculateUsedSubnets(before)
+	if err != nil {
+		return 0, 0, err
+	}
+	afterNets, err := calculateUsedSubnets(after)
+	if err != nil {
+		return 0, 0, err
+	}
+	parentFirst, parentLast := parentNet.addressRange()
+	for _, usedNet := range append(append([]*Subnet{}, beforeNets...), afterNets...) {
+		first, last := usedNet.addressRange()
+		if first < parentFirst || last > parentLast {
+			return 0, 0, fmt.Errorf("subnet %s is not contained in parent %s", usedNet.NetworkCIDR, parent)
+		}
+	}
+
+	beforeRanges := mergeAddressRanges(beforeNets)
+	afterRanges := mergeAddressRanges(afterNets)
+	unchanged := totalSize(intersectAddressRanges(beforeRanges, afterRanges))
+	return totalSize(beforeRanges) - unchanged, totalSize(afterRanges) - unchanged, nil
+}
+
+// SeparatingPrefix returns the shortest prefix length at which the addresses a and b
+// are in different subnets, i.e. their common prefix length plus one.
+func SeparatingPrefix(a, b string) (int, error) {
+	aIPInt, err := parseIPv4ToInt(a)
+	if err != nil {
+		return 0, err
+	}
+	bIPInt, err := parseIPv4ToInt(b)
+	if err != nil {
+		return 0, err
+	}
+	if aIPInt == bIPInt {
+		return 0, fmt.Errorf("identical addresses %s and %s can not be separated", a, b)
+	}
+	return bits.LeadingZeros32(aIPInt^bIPInt) + 1, nil
+}
+
+// ContainedInAny reports whether the subnet lies entirely within one of the
+// allowlist CIDR blocks and returns the first containing block.
+func ContainedInAny(subnet string, allowlist []string) (bool, *Subnet, error) {
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return false, nil, err
+	}
+	allowedNets, err := calculateUsedSubnets(allowlist)
+	if err != nil {
+		return false, nil, err
+	}
+	first, last := subnetNet.addressRange()
+	for _, allowedNet := range allowedNets {
+		allowedFirst, allowedLast := allowedNet.addressRange()
+		if first >= allowedFirst && last <= allowedLast {
+			return true, allowedNet, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// PlanSpec declares the subnets to allocate within a parent CIDR block.
+type PlanSpec struct {
+	Parent       string
+	Requirements []PlanRequirement
+}
+
+// PlanRequirement declares a named subnet either by its number of usable hosts
+// or by its prefix length.
+type PlanRequirement struct {
+	Name   string
+	Hosts  int
+	Prefix int
+}
+
+// Plan allocates non-overlapping subnets for all requirements of the spec.
+// The subnets are packed largest first into the parent CIDR block and returned
+// in the order of the requirements, so the n-th subnet belongs to the n-th requirement.
+func Plan(spec PlanSpec) ([]*Subnet, error) {
+	parentNet, err := calculateIPv4Subnet(spec.Parent)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make([]int, len(spec.Requirements))
+	names := map[string]bool{}
+	for i, requirement := range spec.Requirements {
+		if names[requirement.Name] {
+			return nil, fmt.Errorf("duplicate requirement name %q", requirement.Name)
+		}
+		names[requirement.Name] = true
+		switch {
+		case requirement.Hosts > 0 && requirement.Prefix > 0:
+			return nil, fmt.Errorf("requirement %q must declare either hosts or prefix", requirement.Name)
+		case requirement.Prefix > 0:
+			prefixes[i] = requirement.Prefix
+		default:
+			prefixes[i], err = prefixForHosts(requirement.Hosts)
+			if err != nil {
+				return nil, fmt.Errorf("requirement %q: %w", requirement.Name, err)
+			}
+		}
+	}
+
+	return allocateLargestFirst(parentNet, prefixes)
+}
+
+// allocateLargestFirst allocates a subnet for each of the prefix lengths within
+// the parent subnet, packing them largest first, and returns them in the
+// order of the prefix lengths.
+func allocateLargestFirst(parentNet *Subnet, prefixes []int) ([]*Subnet, error) {
+	order := make([]int, len(prefixes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return prefixes[order[i]] < prefixes[order[j]]
+	})
+	sortedPrefixes := make([]int, len(order))
+	for i, prefixIndex := range order {
+		sortedPrefixes[i] = prefixes[prefixIndex]
+	}
+	allocated, err := allocateBestFit(parentNet, sortedPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	subnets := make([]*Subnet, len(allocated))
+	for i, prefixIndex := range order {
+		subnets[prefixIndex] = allocated[i]
+	}
+	return subnets, nil
+}
+
+// Midpoint returns the address halfway between the network and the broadcast
+// address, which is the first address of the subnet's upper half.
+// It returns nil for IPv6 subnets.
+func (s *Subnet) Midpoint() net.IP {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return nil
+	}
+	first, last := s.addressRange()
+	return intToIP(first + (last-first)/2 + (last-first)%2)
+}
+
+// FindAllOverlaps returns all pairs of overlapping CIDR blocks.
+// The blocks are sorted by their first address and swept once, comparing each block
+// only with the preceding blocks still reaching into it, so disjoint blocks
+// don't add pairwise comparisons. The pairs are ordered by the first address
+// of their second block.
+func FindAllOverlaps(cidrs []string) ([][2]string, error) {
+	subnets, err := calculateUsedSubnets(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(subnets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		iFirst, _ := subnets[order[i]].addressRange()
+		jFirst, _ := subnets[order[j]].addressRange()
+		return iFirst < jFirst
+	})
+
+	var overlaps [][2]string
+	var active []int
+	for _, current := range order {
+		first, _ := subnets[current].addressRange()
+		stillActive := active[:0]
+		for _, previous := range active {
+			if _, previousLast := subnets[previous].addressRange(); previousLast >= first {
+				overlaps = append(overlaps, [2]string{cidrs[previous], cidrs[current]})
+				stillActive = append(stillActive, previous)
+			}
+		}
+		active = append(stillActive, current)
+	}
+	return overlaps, nil
+}
+
+// IPNet returns a copy of the subnet's network which shares no memory with the subnet.
+func (s *Subnet) IPNet() *net.IPNet {
+	return &net.IPNet{
+		IP:   append(net.IP{}, s.Network.IP...),
+		Mask: append(net.IPMask{}, s.Network.Mask...),
+	}
+}
+
+// CalculateSubnetsInRange returns all aligned subnets with the child prefix
+// length fully contained in the IP range from start to end.
+func CalculateSubnetsInRange(start, end string, childPrefix int) ([]*Subnet, error) {
+	if childPrefix < 0 || childPrefix > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", childPrefix)
+	}
+	startIPInt, endIPInt, err := parseIPv4Range(start, end)
+	if err != nil {
+		return nil, err
+	}
+	blockSize := uint64(1) << (32 - childPrefix)
+	var subnets []*Subnet
+	// Round the start up to the next aligned network address.
+	for network := (uint64(startIPInt) + blockSize - 1) / blockSize * blockSize; network+blockSize-1 <= uint64(endIPInt); network += blockSize {
+		subnet, err := CalculateSubnetFromInt(uint32(network), childPrefix)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// subnetJSON is the JSON representation of a subnet.
+type subnetJSON struct {
+	NetworkCIDR string `json:"network_cidr"`
+	Network     string `json:"network"`
+	IP          string `json:"ip"`
+	NetMask     string `json:"netmask"`
+	Broadcast   string `json:"broadcast"`
+	HostMin     string `json:"host_min"`
+	HostMax     string `json:"host_max"`
+	Hosts       int    `json:"hosts"`
+	TotalHosts  int    `json:"total_hosts"`
+}
+
+// MarshalJSON encodes the subnet with all addresses and the netmask in their string form.
+func (s *Subnet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subnetJSON{
+		NetworkCIDR: s.NetworkCIDR,
+		Network:     s.Network.String(),
+		IP:          s.IP.String(),
+		NetMask:     s.MaskString(),
+		Broadcast:   s.BroadcastIP.String(),
+		HostMin:     s.HostMinIP.String(),
+		HostMax:     s.HostMaxIP.String(),
+		Hosts:       s.HostsNum,
+		TotalHosts:  s.TotalHostsNum,
+	})
+}
+
+// UnmarshalJSON decodes a subnet encoded by MarshalJSON
+// and recalculates it from its network CIDR.
+func (s *Subnet) UnmarshalJSON(data []byte) error {
+	var decoded subnetJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	subnet, err := CalculateSubnet(decoded.NetworkCIDR)
+	if err != nil {
+		return err
+	}
+	*s = *subnet
+	return nil
+}
+
+// Contains reports whether the IP is within the subnet's network block,
+// including the network and the broadcast address.
+func (s *Subnet) Contains(ip net.IP) bool {
+	return s.Network.Contains(ip)
+}
+
+// CalculateSubnetsWithStride divides a given CIDR block into subnets with the
+// requested prefix length and returns every stride-th of them, starting with the first.
+func CalculateSubnetsWithStride(parent string, childPrefix int, stride int) ([]*Subnet, error) {
+	if childPrefix < 0 || childPrefix > 32 {
+		return nil, fmt.Errorf("invalid prefix length %d", childPrefix)
+	}
+	if stride < 1 {
+		return nil, fmt.Errorf("invalid stride %d", stride)
+	}
+	subnets, err := CalculateSubnetsByCIDR(parent, uint32(childPrefix))
+	if err != nil {
+		return nil, err
+	}
+	var strided []*Subnet
+	for i := 0; i < len(subnets); i += stride {
+		strided = append(strided, subnets[i])
+	}
+	return strided, nil
+}
+
+// CanDelete reports whether the subnet can be deleted because none of the
+// allocated child CIDR blocks overlaps it, and returns the blocking children.
+func CanDelete(subnet string, children []string) (bool, []string, error) {
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return false, nil, err
+	}
+	childNets, err := calculateUsedSubnets(children)
+	if err != nil {
+		return false, nil, err
+	}
+	first, last := subnetNet.addressRange()
+	var blockers []string
+	for i, childNet := range childNets {
+		if overlapsAny(first, last, []*Subnet{childNet}) {
+			blockers = append(blockers, children[i])
+		}
+	}
+	return len(blockers) == 0, blockers, nil
+}
+
+// HostIPs returns an iterator lazily yielding the IP addresses from the
+// minimal to the maximal host address.
+func (s *Subnet) HostIPs() iter.Seq[net.IP] {
+	return func(yield func(net.IP) bool) {
+		host, okMin := netip.AddrFromSlice(s.HostMinIP)
+		lastHost, okMax := netip.AddrFromSlice(s.HostMaxIP)
+		if !okMin || !okMax {
+			return
+		}
+		for ; host.IsValid() && host.Compare(lastHost) <= 0; host = host.Next() {
+			if !yield(host.AsSlice()) {
+				return
+			}
+		}
+	}
+}
+
+// Aggregate merges adjacent and overlapping CIDR blocks into the minimal list
+// of aligned supernets covering exactly the same addresses, sorted by network address.
+func Aggregate(cidrs []string) ([]string, error) {
+	subnets, err := calculateUsedSubnets(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	aggregated := []string{}
+	for _, r := range mergeAddressRanges(subnets) {
+		supernets, err := rangeToCIDRs(r.first, r.last)
+		if err != nil {
+			return nil, err
+		}
+		for _, supernet := range supernets {
+			aggregated = append(aggregated, supernet.NetworkCIDR)
+		}
+	}
+	return aggregated, nil
+}
+
+// HostBitsUsed returns the number of bits needed to index maxHost hosts,
+// i.e. ceil(log2(maxHost+1)), bounded by the subnet's host bits.
+func (s *Subnet) HostBitsUsed(maxHost int) int {
+	if maxHost < 1 {
+		return 0
+	}
+	ones, bitsTotal := s.NetworkMask.Size()
+	return min(bits.Len(uint(maxHost)), bitsTotal-ones)
+}
+
+// Overlaps reports whether the CIDR blocks a and b share any address.
+// Two CIDR blocks overlap exactly if one contains the network address of the other.
+func Overlaps(a, b string) (bool, error) {
+	aNet, err := CalculateSubnet(a)
+	if err != nil {
+		return false, err
+	}
+	bNet, err := CalculateSubnet(b)
+	if err != nil {
+		return false, err
+	}
+	return aNet.Contains(bNet.Network.IP) || bNet.Contains(aNet.Network.IP), nil
+}
+
+// CalculateSubnetsByCIDRExcluding divides a given CIDR block into subnets with the
+// requested prefix length and returns only those not overlapping any blocklist entry.
+func CalculateSubnetsByCIDRExcluding(parent string, childPrefix uint32, blocklist []string) ([]*Subnet, error) {
+	blockedNets, err := calculateUsedSubnets(blocklist)
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := CalculateSubnetsByCIDR(parent, childPrefix)
+	if err != nil {
+		return nil, err
+	}
+	allowed := []*Subnet{}
+	for _, subnet := range subnets {
+		first, last := subnet.addressRange()
+		if !overlapsAny(first, last, blockedNets) {
+			allowed = append(allowed, subnet)
+		}
+	}
+	return allowed, nil
+}
+
+// AllocateVLSM allocates a subnet for each of the required host counts within
+// the parent CIDR block, each sized to the longest prefix length providing enough hosts.
+// The subnets are packed contiguously largest first, which keeps all of them
+// aligned, and returned in this allocation order.
+func AllocateVLSM(cidr string, hostCounts []int) ([]*Subnet, error) {
+	parentNet, err := calculateIPv4Subnet(cidr)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make([]int, len(hostCounts))
+	for i, hosts := range hostCounts {
+		prefixes[i], err = prefixForHosts(hosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Ints(prefixes)
+
+	parentFirst, parentLast := parentNet.addressRange()
+	requiredAddresses := uint64(0)
+	for _, prefix := range prefixes {
+		requiredAddresses += uint64(1) << (32 - prefix)
+	}
+	if requiredAddresses > uint64(parentLast)-uint64(parentFirst)+1 {
+		return nil, fmt.Errorf("required %d addresses exceed the %d addresses of %s",
+			requiredAddresses, uint64(parentLast)-uint64(parentFirst)+1, cidr)
+	}
+
+	subnets := make([]*Subnet, 0, len(prefixes))
+	network := uint64(parentFirst)
+	for _, prefix := range prefixes {
+		subnet, err := CalculateSubnetFromInt(uint32(network), prefix)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, subnet)
+		network += uint64(1) << (32 - prefix)
+	}
+	return subnets, nil
+}
+
+// JunosRouteFilter returns a Junos policy route-filter term for the subnet's network,
+// e.g. "route-filter 10.0.0.0/24 exact;". The match type defaults to exact and
+// can be set to another type like longer or orlonger by the optional modifier.
+func (s *Subnet) JunosRouteFilter(modifier ...string) string {
+	matchType := "exact"
+	if len(modifier) > 0 && modifier[0] != "" {
+		matchType = modifier[0]
+	}
+	return fmt.Sprintf("route-filter %s %s;", s.Network.String(), matchType)
+}
+
+// LargestFreeSubnet returns the largest aligned subnet within the parent CIDR block
+// not overlapping any used CIDR block. Of equally sized subnets the first is returned.
+func LargestFreeSubnet(parent string, used []string) (*Subnet, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	usedNets, err := calculateUsedSubnets(used)
+	if err != nil {
+		return nil, err
+	}
+	parentFirst, parentLast := parentNet.addressRange()
+	var largest *Subnet
+	largestOnes := 33
+	for _, free := range subtractAddressRanges(ipRange{first: parentFirst, last: parentLast}, mergeAddressRanges(usedNets)) {
+		freeNets, err := rangeToCIDRs(free.first, free.last)
+		if err != nil {
+			return nil, err
+		}
+		for _, freeNet := range freeNets {
+			if ones, _ := freeNet.NetworkMask.Size(); ones < largestOnes {
+				largest = freeNet
+				largestOnes = ones
+			}
+		}
+	}
+	if largest == nil {
+		return nil, fmt.Errorf("no free subnet left in %s", parent)
+	}
+	return largest, nil
+}
+
+// PrefixLen returns the prefix length of the subnet's network mask.
+func (s *Subnet) PrefixLen() int {
+	ones, _ := s.NetworkMask.Size()
+	return ones
+}
+
+// StraddlesParents reports whether the subnet spans more than one parent block
+// with the given prefix length.
+func StraddlesParents(subnet string, parentPrefix int) (bool, error) {
+	if parentPrefix < 0 || parentPrefix > 32 {
+		return false, fmt.Errorf("invalid prefix length %d", parentPrefix)
+	}
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return false, err
+	}
+	first, last := subnetNet.addressRange()
+	parentMask := ipToInt(net.IP(net.CIDRMask(parentPrefix, 32)))
+	return first&parentMask != last&parentMask, nil
+}
+
+// AllocateByPercent allocates a subnet for each of the percentages of the parent
+// CIDR block's addresses, returned in the order of the percentages.
+// The percentages must sum up to 100. Each share is rounded to the nearest
+// power of two, rounding down on ties, so the subnets may not fill the parent
+// completely. Rounding up can exceed the parent, which results in an error.
+func AllocateByPercent(parent string, percents []int) ([]*Subnet, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	sum := 0
+	for _, percent := range percents {
+		if percent < 1 {
+			return nil, fmt.Errorf("invalid percentage %d", percent)
+		}
+		sum += percent
+	}
+	if sum != 100 {
+		return nil, fmt.Errorf("percentages sum up to %d instead of 100", sum)
+	}
+
+	parentOnes := parentNet.PrefixLen()
+	parentAddresses := float64(uint64(1) << (32 - parentOnes))
+	prefixes := make([]int, len(percents))
+	for i, percent := range percents {
+		share := parentAddresses * float64(percent) / 100
+		hostBits := max(int(math.Floor(math.Log2(share))), 0)
+		if share-math.Exp2(float64(hostBits)) > math.Exp2(float64(hostBits+1))-share {
+			hostBits++
+		}
+		prefixes[i] = 32 - hostBits
+	}
+	return allocateLargestFirst(parentNet, prefixes)
+}
+
+// Split divides the subnet into child subnets with the requested prefix length.
+func (s *Subnet) Split(newPrefix int) ([]*Subnet, error) {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return nil, err
+	}
+	ones := s.PrefixLen()
+	if newPrefix <= ones || newPrefix > 32 {
+		return nil, fmt.Errorf("prefix length /%d must be longer than /%d and at most /32", newPrefix, ones)
+	}
+	first, _ := s.addressRange()
+	childCount := uint64(1) << (newPrefix - ones)
+	children := make([]*Subnet, 0, childCount)
+	for i := uint64(0); i < childCount; i++ {
+		children = append(children, newIPv4Subnet(first|uint32(i<<(32-newPrefix)), newPrefix))
+	}
+	return children, nil
+}
+
+// HostsExcluding calculates the host addresses between the minimal and the
+// maximal host address of the CIDR block without the addresses of the
+// excluded CIDR block, which must be contained in the CIDR block.
+func HostsExcluding(cidr string, excluded string) ([]net.IP, error) {
+	ipnet, err := calculateIPv4Subnet(cidr)
+	if err != nil {
+		return nil, err
+	}
+	excludedNet, err := calculateIPv4Subnet(excluded)
+	if err != nil {
+		return nil, err
+	}
+	first, last := ipnet.addressRange()
+	excludedFirst, excludedLast := excludedNet.addressRange()
+	if excludedFirst < first || excludedLast > last {
+		return nil, fmt.Errorf("excluded subnet %s is not contained in %s", excluded, cidr)
+	}
+	return FilterHosts(cidr, func(ip net.IP) bool {
+		host := ipToInt(ip)
+		return host < excludedFirst || host > excludedLast
+	})
+}
+
+// ReverseDNSZones returns the in-addr.arpa zone names covering the subnet.
+// Subnets on an octet boundary map to a single zone, longer prefixes than /24
+// map to the RFC 2317 classless delegation zone, e.g. 0/26.1.168.192.in-addr.arpa,
+// and other prefixes map to all zones of the next longer octet boundary.
+// It returns nil for IPv6 subnets.
+func (s *Subnet) ReverseDNSZones() []string {
+	first, err := ipv4ToInt(s.Network.IP)
+	if err != nil {
+		return nil
+	}
+	ones := s.PrefixLen()
+	if ones > 24 && ones < 32 {
+		return []string{fmt.Sprintf("%d/%d.%s", first&0xFF, ones, reverseDNSZone(first, 3))}
+	}
+	octets := (ones + 7) / 8
+	zoneCount := 1 << (octets*8 - ones)
+	zones := make([]string, 0, zoneCount)
+	for i := 0; i < zoneCount; i++ {
+		zones = append(zones, reverseDNSZone(first+uint32(i)<<(32-octets*8), octets))
+	}
+	return zones
+}
+
+// reverseDNSZone returns the in-addr.arpa zone name for the leading octets of the address.
+func reverseDNSZone(address uint32, octets int) string {
+	IPBytes := intToIP(address)
+	labels := make([]string, 0, octets+1)
+	for i := octets - 1; i >= 0; i-- {
+		labels = append(labels, strconv.Itoa(int(IPBytes[i])))
+	}
+	return strings.Join(append(labels, "in-addr.arpa"), ".")
+}
+
+// Next returns the subnet with the same prefix length immediately following the subnet.
+func (s *Subnet) Next() (*Subnet, error) {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return nil, err
+	}
+	_, last := s.addressRange()
+	if last == 0xFFFFFFFF {
+		return nil, fmt.Errorf("subnet %s has no next subnet", s.Network.String())
+	}
+	return CalculateSubnetFromInt(last+1, s.PrefixLen())
+}
+
+// Previous returns the subnet with the same prefix length immediately preceding the subnet.
+func (s *Subnet) Previous() (*Subnet, error) {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return nil, err
+	}
+	first, _ := s.addressRange()
+	if first == 0 {
+		return nil, fmt.Errorf("subnet %s has no previous subnet", s.Network.String())
+	}
+	ones := s.PrefixLen()
+	return CalculateSubnetFromInt(first-uint32(1<<(32-ones)), ones)
+}
+
+// WriteSubnetsCSV writes the subnets as CSV with a header row and one row per subnet.
+func WriteSubnetsCSV(w io.Writer, subnets []*Subnet) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"network_cidr", "host_min", "host_max", "broadcast", "hosts", "total_hosts"}); err != nil {
+		return err
+	}
+	for _, subnet := range subnets {
+		record := []string{
+			subnet.NetworkCIDR,
+			subnet.HostMinIP.String(),
+			subnet.HostMaxIP.String(),
+			subnet.BroadcastIP.String(),
+			strconv.Itoa(subnet.HostsNum),
+			strconv.Itoa(subnet.TotalHostsNum),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// AllocationTableEntry describes a child subnet with the offsets of its first
+// and its last address within the parent CIDR block.
+type AllocationTableEntry struct {
+	Subnet      *Subnet
+	StartOffset int
+	EndOffset   int
+}
+
+// AllocationTable divides the parent CIDR block into subnets with the requested
+// prefix length and returns them with their address offsets within the parent.
+func AllocationTable(parent string, childPrefix int) ([]AllocationTableEntry, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	children, err := parentNet.Split(childPrefix)
+	if err != nil {
+		return nil, err
+	}
+	parentFirst, _ := parentNet.addressRange()
+	table := make([]AllocationTableEntry, len(children))
+	for i, child := range children {
+		first, last := child.addressRange()
+		table[i] = AllocationTableEntry{
+			Subnet:      child,
+			StartOffset: int(first - parentFirst),
+			EndOffset:   int(last - parentFirst),
+		}
+	}
+	return table, nil
+}
+
+// IsMergeReady reports whether the subnet starts at a block boundary of the
+// target prefix length, so it could later be merged up to the target prefix length
+// as the lower sibling of each step.
+func (s *Subnet) IsMergeReady(targetPrefix int) (bool, error) {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return false, err
+	}
+	bits, _, ok := s.subnetBits(targetPrefix)
+	if !ok {
+		return false, fmt.Errorf("target prefix /%d must be shorter than subnet prefix /%d", targetPrefix, s.PrefixLen())
+	}
+	return bits == 0, nil
+}
+
+// Class returns the classful address class "A", "B", "C", "D" (multicast)
+// or "E" (reserved) of the subnet's IP address, and an empty string for IPv6.
+func (s *Subnet) Class() string {
+	IPBytes := s.IP.To4()
+	if IPBytes == nil {
+		return ""
+	}
+	// The class is determined by the number of leading one bits of the first octet.
+	classes := []string{"A", "B", "C", "D", "E"}
+	return classes[min(bits.LeadingZeros8(^IPBytes[0]), 4)]
+}
+
+// privateAddressBlocks are the private IPv4 blocks of RFC 1918.
+var privateAddressBlocks = []ipRange{
+	{first: 0x0A000000, last: 0x0AFFFFFF}, // 10.0.0.0/8
+	{first: 0xAC100000, last: 0xAC1FFFFF}, // 172.16.0.0/12
+	{first: 0xC0A80000, last: 0xC0A8FFFF}, // 192.168.0.0/16
+}
+
+// IsPrivate reports whether the IPv4 address is within one of the RFC 1918 private blocks.
+func IsPrivate(ip net.IP) bool {
+	IPInt, err := ipv4ToInt(ip)
+	if err != nil {
+		return false
+	}
+	for _, block := range privateAddressBlocks {
+		if IPInt >= block.first && IPInt <= block.last {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanJSON divides the parent CIDR block into subnets with the requested
+// prefix length and returns them encoded as JSON array.
+func PlanJSON(parent string, childPrefix int) ([]byte, error) {
+	parentNet, err := calculateIPv4Subnet(parent)
+	if err != nil {
+		return nil, err
+	}
+	children, err := parentNet.Split(childPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(children)
+}
+
+// DetailedString returns a multi-line description of the subnet similar to the
+// output of ipcalc, with the binary form of each address and the netmask.
+// The bits of the binary forms are separated by a space at the prefix length.
+// The layout fits IPv4 subnets only, so an empty string is returned for IPv6 subnets.
+func (s *Subnet) DetailedString() string {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return ""
+	}
+	ones := s.PrefixLen()
+	var b strings.Builder
+	line := func(label, value string, address []byte) {
+		fmt.Fprintf(&b, "%-11s%-21s%s\n", label, value, binaryString(address, ones))
+	}
+	line("Address:", s.IP.String(), s.IP)
+	line("Netmask:", fmt.Sprintf("%s = %d", s.MaskString(), ones), s.NetworkMask)
+	line("Wildcard:", net.IP(s.WildcardMask).String(), s.WildcardMask)
+	b.WriteString("=>\n")
+	line("Network:", s.Network.String(), s.Network.IP)
+	line("HostMin:", s.HostMinIP.String(), s.HostMinIP)
+	line("HostMax:", s.HostMaxIP.String(), s.HostMaxIP)
+	line("Broadcast:", s.BroadcastIP.String(), s.BroadcastIP)
+	hosts := fmt.Sprintf("%-11s%-21d", "Hosts/Net:", s.HostsNum)
+	if class := s.Class(); class != "" {
+		hosts += "Class " + class
+		if IsPrivate(s.IP) {
+			hosts += ", Private Internet"
+		}
+	}
+	b.WriteString(strings.TrimRight(hosts, " ") + "\n")
+	return b.String()
+}
+
+// binaryString returns the bits of the address as dot separated octets
+// with a space inserted after the first ones bits.
+func binaryString(address []byte, ones int) string {
+	if IPBytes := net.IP(address).To4(); IPBytes != nil {
+		address = IPBytes
+	}
+	var b strings.Builder
+	for i, octet := range address {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		for bit := 0; bit < 8; bit++ {
+			if position := i*8 + bit; position == ones && position > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteByte('0' + octet>>(7-bit)&1)
+		}
+	}
+	return b.String()
+}
+
+// RemainingHosts returns the number of usable hosts of the subnet not assigned yet.
+// Duplicate assignments and assigned addresses outside of the host range are ignored.
+func (s *Subnet) RemainingHosts(assigned []string) (int, error) {
+	hostMinIP, hostMaxIP := s.HostRange()
+	hostMin, err := ipv4ToInt(hostMinIP)
+	if err != nil {
+		return 0, err
+	}
+	hostMax := ipToInt(hostMaxIP)
+	assignedHosts := map[uint32]bool{}
+	for _, ip := range assigned {
+		host, err := parseIPv4ToInt(ip)
+		if err != nil {
+			return 0, err
+		}
+		if host >= hostMin && host <= hostMax {
+			assignedHosts[host] = true
+		}
+	}
+	return int(hostMax-hostMin+1) - len(assignedHosts), nil
+}
+
+// Equal reports whether both subnets have the same network address and prefix length.
+func (s *Subnet) Equal(other *Subnet) bool {
+	return s.Compare(other) == 0
+}
+
+// Compare orders subnets by their network address and then by their prefix length.
+// It returns -1 if the subnet sorts before the other subnet, +1 if it sorts after it and 0 if they are equal.
+func (s *Subnet) Compare(other *Subnet) int {
+	if c := bytes.Compare(s.Network.IP.To16(), other.Network.IP.To16()); c != 0 {
+		return c
+	}
+	return cmp.Compare(s.PrefixLen(), other.PrefixLen())
+}
+
+// ApproximateCover returns at most maxRoutes subnets covering all CIDR blocks
+// and the number of extra addresses covered beyond the CIDR blocks.
+// Starting with the exact aggregation, it repeatedly replaces two neighbouring
+// subnets and everything in between by their smallest common supernet,
+// choosing the supernet adding the fewest extra addresses.
+func ApproximateCover(cidrs []string, maxRoutes int) ([]*Subnet, int64, error) {
+	if maxRoutes < 1 {
+		return nil, 0, fmt.Errorf("invalid maximal route count %d", maxRoutes)
+	}
+	subnets, err := calculateUsedSubnets(cidrs)
+	if err != nil {
+		return nil, 0, err
+	}
+	merged := mergeAddressRanges(subnets)
+	var cover []ipRange
+	for _, r := range merged {
+		supernets, err := rangeToCIDRs(r.first, r.last)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, supernet := range supernets {
+			first, last := supernet.addressRange()
+			cover = append(cover, ipRange{first: first, last: last})
+		}
+	}
+
+	for len(cover) > maxRoutes {
+		var best ipRange
+		bestStart, bestEnd, bestExtra := 0, 0, int64(math.MaxInt64)
+		for i := 0; i+1 < len(cover); i++ {
+			// The smallest common supernet shares the leading bits of both blocks.
+			hostMask := uint32(0xFFFFFFFF) >> bits.LeadingZeros32(cover[i].first^cover[i+1].last)
+			supernet := ipRange{first: cover[i].first &^ hostMask, last: cover[i].first | hostMask}
+			start, end := i, i+1
+			for start > 0 && cover[start-1].first >= supernet.first {
+				start--
+			}
+			for end+1 < len(cover) && cover[end+1].last <= supernet.last {
+				end++
+			}
+			if extra := supernet.size() - totalSize(cover[start:end+1]); extra < bestExtra {
+				best, bestStart, bestEnd, bestExtra = supernet, start, end, extra
+			}
+		}
+		cover = append(cover[:bestStart], append([]ipRange{best}, cover[bestEnd+1:]...)...)
+	}
+
+	covering := make([]*Subnet, 0, len(cover))
+	for _, r := range cover {
+		subnet, err := CalculateSubnetFromInt(r.first, 32-bits.Len64(uint64(r.size()-1)))
+		if err != nil {
+			return nil, 0, err
+		}
+		covering = append(covering, subnet)
+	}
+	return covering, totalSize(cover) - totalSize(merged), nil
+}
+
+// IPToBinaryString returns the 32 bits of the IPv4 address as dot separated octets,
+// e.g. 11000000.10101000.00000001.00001010 for 192.168.1.10.
+// It returns an empty string for IPv6 addresses.
+func IPToBinaryString(ip net.IP) string {
+	IPInt, err := ipv4ToInt(ip)
+	if err != nil {
+		return ""
+	}
+	octets := make([]string, 4)
+	for i := range octets {
+		octets[i] = fmt.Sprintf("%08b", IPInt>>(24-8*i)&0xFF)
+	}
+	return strings.Join(octets, ".")
+}
+
+// NetworkMaskBinary returns the bits of the IPv4 subnet's netmask as dot separated octets.
+// It returns an empty string for IPv6 subnets.
+func (s *Subnet) NetworkMaskBinary() string {
+	return IPToBinaryString(net.IP(s.NetworkMask))
+}
+
+// Broadcast returns the broadcast address of the IPv4 subnet.
+// A /32 has no distinct broadcast address, so the single address is returned.
+// A /31 point-to-point link (RFC 3021) has no broadcast address and nil is returned,
+// while BroadcastIP keeps the legacy value of the higher of both addresses.
+// IPv6 knows no broadcast address, so nil is returned for IPv6 subnets.
+func (s *Subnet) Broadcast() net.IP {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return nil
+	}
+	if s.PrefixLen() == 31 {
+		return nil
+	}
+	_, last := s.addressRange()
+	return intToIP(last)
+}
+
+// AdjacencyEdges returns the index pairs of all subnets directly following
+// each other, where the address after the last address of one subnet is the
+// network address of the other. Each pair holds the lower index first and the
+// pairs are sorted by their indices.
+func AdjacencyEdges(subnets []*Subnet) ([][2]int, error) {
+	byNetwork := map[uint32][]int{}
+	for i, subnet := range subnets {
+		first, err := ipv4ToInt(subnet.Network.IP)
+		if err != nil {
+			return nil, err
+		}
+		byNetwork[first] = append(byNetwork[first], i)
+	}
+	edges := [][2]int{}
+	for i, subnet := range subnets {
+		_, last := subnet.addressRange()
+		if last == 0xFFFFFFFF {
+			continue
+		}
+		for _, j := range byNetwork[last+1] {
+			edges = append(edges, [2]int{min(i, j), max(i, j)})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	return edges, nil
+}
+
+// HostRange returns the first and the last usable host address of the subnet's
+// network block, stripping the network and the broadcast address.
+// IPv6 subnets, /31 point-to-point links and /32 single hosts
+// have no reserved addresses, so the whole block is usable.
+func (s *Subnet) HostRange() (first, last net.IP) {
+	if _, err := ipv4ToInt(s.Network.IP); err != nil {
+		return s.HostMinIP, s.HostMaxIP
+	}
+	firstInt, lastInt := s.addressRange()
+	if s.PrefixLen() < 31 {
+		firstInt++
+		lastInt--
+	}
+	return intToIP(firstInt), intToIP(lastInt)
+}
+
+// RightSize returns the smallest subnet at the subnet's network address
+// still providing the peak number of usable hosts.
+func RightSize(subnet string, peakHosts int) (*Subnet, error) {
+	subnetNet, err := calculateIPv4Subnet(subnet)
+	if err != nil {
+		return nil, err
+	}
+	rightSizedOnes, err := prefixForHosts(peakHosts)
+	if err != nil {
+		return nil, err
+	}
+	if ones := subnetNet.PrefixLen(); rightSizedOnes < ones {
+		return nil, fmt.Errorf("%d hosts require a /%d which does not fit into subnet %s", peakHosts, rightSizedOnes, subnet)
+	}
+	first, _ := subnetNet.addressRange()
+	return CalculateSubnetFromInt(first, rightSizedOnes)
+}
+
+// ExplainSubnet returns the steps of calculating the IPv4 CIDR block's subnet
+// as human-readable lines in calculation order.
+func ExplainSubnet(cidr string) ([]string, error) {
+	subnet, err := calculateIPv4Subnet(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ones := subnet.PrefixLen()
+	first, last := subnet.addressRange()
+	hostMin, hostMax := subnet.HostRange()
+	steps := []string{
+		fmt.Sprintf("Prefix /%d sets the first %d of 32 bits of the netmask: %s", ones, ones, subnet.MaskString()),
+		fmt.Sprintf("Applying the netmask %s to %s results in the network address %s", subnet.MaskString(), subnet.IP, intToIP(first)),
+		fmt.Sprintf("The remaining %d host bits address %d addresses", 32-ones, uint64(last-first)+1),
+		fmt.Sprintf("Setting all host bits of the network address results in the broadcast address %s", intToIP(last)),
+	}
+	if ones < 31 {
+		steps = append(steps, fmt.Sprintf("Stripping the network and the broadcast address leaves %d usable hosts from %s to %s",
+			ipToInt(hostMax)-ipToInt(hostMin)+1, hostMin, hostMax))
+	} else {
+		steps = append(steps, fmt.Sprintf("A /%d reserves no network and broadcast address, leaving %d usable hosts from %s to %s",
+			ones, ipToInt(hostMax)-ipToInt(hostMin)+1, hostMin, hostMax))
+	}
+	return steps, nil
+}
+
+// AddressCount returns the number of addresses of the subnet's network block.
+// Counts of IPv6 subnets exceeding uint64 are capped at math.MaxUint64.
+func (s *Subnet) AddressCount() uint64 {
+	ones, maskBits := s.NetworkMask.Size()
+	count := new(big.Int).Lsh(big.NewInt(1), uint(maskBits-ones))
+	if !count.IsUint64() {
+		return math.MaxUint64
+	}
+	return count.Uint64()
+}
+
+// IntersectLists returns the minimal list of subnets covering the address
+// space covered by both lists of CIDR blocks.
+func IntersectLists(a, b []string) ([]*Subnet, error) {
+	aNets, err := calculateUsedSubnets(a)
+	if err != nil {
+		return nil, err
+	}
+	bNets, err := calculateUsedSubnets(b)
+	if err != nil {
+		return nil, err
+	}
+	intersection := []*Subnet{}
+	for _, r := range intersectAddressRanges(mergeAddressRanges(aNets), mergeAddressRanges(bNets)) {
+		subnets, err := rangeToCIDRs(r.first, r.last)
+		if err != nil {
+			return nil, err
+		}
+		intersection = append(intersection, subnets...)
+	}
+	return intersection, nil
+}